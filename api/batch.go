@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"oapi-sdk-go-demo/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 批量发送最多支持的接收者数量
+const maxBatchReceivers = 500
+
+// 批量发送消息请求结构
+// receivers 可混合 user_id、open_id、union_id、email、chat_id、mobile 类型的接收者，
+// broadcast 使用飞书批量发送消息接口向部门或用户列表广播
+type BatchSendRequest struct {
+	Receivers   []*service.BatchReceiver  `json:"receivers"`
+	Broadcast   *service.BroadcastRequest `json:"broadcast"`
+	MsgType     string                    `json:"msg_type" binding:"required"`
+	Content     json.RawMessage           `json:"content" binding:"required"`
+	Concurrency int                       `json:"concurrency"`
+	Uuid        string                    `json:"uuid"`
+}
+
+// 批量发送消息
+func batchSendMessage(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req BatchSendRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if len(req.Receivers) == 0 && req.Broadcast == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "receivers和broadcast不能同时为空"})
+			return
+		}
+		if len(req.Receivers) > maxBatchReceivers {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("receivers数量不能超过%d", maxBatchReceivers)})
+			return
+		}
+		for i, receiver := range req.Receivers {
+			if receiver == nil || receiver.ReceiveIdType == "" || receiver.ReceiveId == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("第%d个接收者缺少receive_id_type或receive_id", i+1)})
+				return
+			}
+		}
+
+		opts := []service.SendOption{service.WithCaller(callerIdentity(c))}
+		if key := c.GetHeader("Idempotency-Key"); key != "" {
+			req.Uuid = key
+		}
+		if len(req.Uuid) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("幂等键长度不能超过%d个字符", maxIdempotencyKeyLength)})
+			return
+		}
+		if req.Uuid != "" {
+			opts = append(opts, service.WithUuid(req.Uuid))
+		}
+
+		content := string(req.Content)
+		response := gin.H{}
+
+		if len(req.Receivers) > 0 {
+			results := feishuService.BatchSendMessage(req.Receivers, req.MsgType, content, req.Concurrency, opts...)
+			succeeded := 0
+			for _, result := range results {
+				if result.Success {
+					succeeded++
+				}
+			}
+			response["results"] = results
+			response["total"] = len(results)
+			response["succeeded"] = succeeded
+			response["failed"] = len(results) - succeeded
+		}
+
+		if req.Broadcast != nil {
+			broadcast, err := feishuService.BroadcastMessage(req.Broadcast, req.MsgType, content, opts...)
+			if err != nil {
+				// 只有广播时广播失败即为请求失败，与逐个发送混合时在结果中返回广播错误
+				if len(req.Receivers) == 0 {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "广播失败: " + err.Error()})
+					return
+				}
+				response["broadcast_error"] = err.Error()
+			} else {
+				response["broadcast"] = broadcast
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"oapi-sdk-go-demo/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 自定义机器人接口的错误码，与飞书自定义机器人保持一致，便于调用方沿用原有的结果判断
+const (
+	botHookCodeBadRequest   = 9499
+	botHookCodeInvalidToken = 19001
+	botHookCodeSignMismatch = 19021
+)
+
+// 接收自定义机器人格式的消息并由应用机器人转发，请求与响应格式与飞书自定义机器人一致
+func botHookWebhook(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var msg service.BotHookMessage
+		if err := c.ShouldBindJSON(&msg); err != nil {
+			respondBotHook(c, http.StatusOK, botHookCodeBadRequest, "Bad Request")
+			return
+		}
+
+		_, err := feishuService.SendBotHookMessage(c.Param("token"), &msg)
+		if err != nil {
+			var feishuErr *service.FeishuError
+			switch {
+			case errors.Is(err, service.ErrBotHookNotFound):
+				respondBotHook(c, http.StatusOK, botHookCodeInvalidToken, "param invalid: "+err.Error())
+			case errors.Is(err, service.ErrBotHookSignMismatch):
+				respondBotHook(c, http.StatusOK, botHookCodeSignMismatch, err.Error())
+			case errors.Is(err, service.ErrBotHookBadRequest):
+				respondBotHook(c, http.StatusOK, botHookCodeBadRequest, err.Error())
+			case errors.As(err, &feishuErr):
+				respondBotHook(c, http.StatusOK, feishuErr.Code, feishuErr.Msg)
+			default:
+				log.Printf("Failed to forward bot hook message: %v", err)
+				respondBotHook(c, http.StatusInternalServerError, -1, err.Error())
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"StatusCode":    0,
+			"StatusMessage": "success",
+			"code":          0,
+			"msg":           "success",
+			"data":          gin.H{},
+		})
+	}
+}
+
+// respondBotHook 返回自定义机器人格式的错误结果
+func respondBotHook(c *gin.Context, status, code int, msg string) {
+	c.JSON(status, gin.H{
+		"code": code,
+		"msg":  msg,
+		"data": gin.H{},
+	})
+}
+
+// 创建自定义机器人兼容地址
+func createBotHook(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var hook service.BotHook
+		if err := c.ShouldBindJSON(&hook); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := feishuService.CreateBotHook(&hook); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"hook": hook,
+				"path": "/hook/" + hook.Token,
+			},
+		})
+	}
+}
+
+// 获取自定义机器人兼容地址列表
+func getBotHookList(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hooks, err := feishuService.ListBotHooks()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    hooks,
+		})
+	}
+}
+
+// 删除自定义机器人兼容地址
+func deleteBotHook(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deleted, err := feishuService.DeleteBotHook(c.Param("token"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "地址不存在"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "地址删除成功",
+		})
+	}
+}
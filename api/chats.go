@@ -0,0 +1,183 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"oapi-sdk-go-demo/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 群成员变更请求结构，members 可混合 user_id、open_id、union_id、email、mobile
+type ChatMembersRequest struct {
+	Members []*service.UserIdentifier `json:"members" binding:"required"`
+}
+
+// validateMembers 校验用户标识列表
+func validateMembers(members []*service.UserIdentifier) error {
+	for i, member := range members {
+		if member == nil || member.Id == "" {
+			return fmt.Errorf("第%d个成员缺少id", i+1)
+		}
+		if !service.IsUserIdentifierType(member.IdType) {
+			return fmt.Errorf("第%d个成员的id_type不支持: %s", i+1, member.IdType)
+		}
+	}
+	return nil
+}
+
+// 创建群
+func createChat(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req service.ChatSpec
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name不能为空"})
+			return
+		}
+		if req.ChatType != "" && req.ChatType != "private" && req.ChatType != "public" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "chat_type只支持private、public"})
+			return
+		}
+		if err := validateMembers(req.Members); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Owner != nil {
+			if err := validateMembers([]*service.UserIdentifier{req.Owner}); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "owner: " + err.Error()})
+				return
+			}
+		}
+		if key := c.GetHeader("Idempotency-Key"); key != "" {
+			req.Uuid = key
+		}
+
+		chat, err := feishuService.CreateChat(&req)
+		if err != nil {
+			respondSendError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    chat,
+		})
+	}
+}
+
+// 获取机器人所在的群列表
+func getChatList(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+		chats, err := feishuService.ListChats(c.Query("page_token"), pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    chats,
+		})
+	}
+}
+
+// 获取群信息
+func getChat(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chat, err := feishuService.GetChat(c.Param("chat_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    chat,
+		})
+	}
+}
+
+// 解散群
+func disbandChat(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chatId := c.Param("chat_id")
+
+		if err := feishuService.DisbandChat(chatId); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "群已解散",
+		})
+	}
+}
+
+// 拉用户入群
+func addChatMembers(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ChatMembersRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(req.Members) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "members不能为空"})
+			return
+		}
+		if err := validateMembers(req.Members); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := feishuService.AddChatMembers(c.Param("chat_id"), req.Members)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    result,
+		})
+	}
+}
+
+// 将用户移出群
+func removeChatMembers(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ChatMembersRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(req.Members) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "members不能为空"})
+			return
+		}
+		if err := validateMembers(req.Members); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := feishuService.RemoveChatMembers(c.Param("chat_id"), req.Members)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    result,
+		})
+	}
+}
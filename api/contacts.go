@@ -0,0 +1,175 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"oapi-sdk-go-demo/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 获取通讯录缓存同步状态
+func getContactSyncStatus(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    feishuService.ContactSyncStatus(),
+		})
+	}
+}
+
+// 立即触发一次通讯录同步，同步在后台执行
+func triggerContactSync(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := feishuService.TriggerContactSync(); err != nil {
+			if errors.Is(err, service.ErrContactSyncRunning) {
+				c.JSON(http.StatusConflict, gin.H{"error": "通讯录同步正在进行中"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"data":    feishuService.ContactSyncStatus(),
+		})
+	}
+}
+
+// 获取部门成员，recursive=true 时包含所有子部门，max_depth 限制向下遍历的层数
+func getDepartmentUsers(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		departmentId := c.Param("id")
+		recursive := c.Query("recursive") == "true"
+
+		maxDepth := 0
+		if value := c.Query("max_depth"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "max_depth必须为非负整数"})
+				return
+			}
+			maxDepth = n
+		}
+
+		result, err := feishuService.ListDepartmentUsers(departmentId, recursive, maxDepth)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    result,
+			"count":   len(result.Users),
+		})
+	}
+}
+
+// 导出部门组织架构，format 为 csv（花名册）、json（部门层级与花名册）或 dot（Graphviz 图）
+func exportDepartment(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		departmentId := c.Param("id")
+		format := c.DefaultQuery("format", "json")
+		if format != "csv" && format != "json" && format != "dot" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format只支持csv、json、dot"})
+			return
+		}
+
+		chart, err := feishuService.ExportOrgChart(departmentId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if format == "json" {
+			c.JSON(http.StatusOK, gin.H{
+				"success": true,
+				"data":    chart,
+			})
+			return
+		}
+
+		var buf bytes.Buffer
+		contentType := "text/csv; charset=utf-8"
+		if format == "csv" {
+			// 写入 BOM 以便 Excel 正确识别中文
+			buf.WriteString("\xEF\xBB\xBF")
+			err = chart.WriteCSV(&buf)
+		} else {
+			contentType = "text/vnd.graphviz; charset=utf-8"
+			err = chart.WriteDOT(&buf)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		filename := fmt.Sprintf("org_%s_%s.%s", departmentId, time.Now().Format("20060102"), format)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Data(http.StatusOK, contentType, buf.Bytes())
+	}
+}
+
+// 用户ID转换请求结构，ids 可混合 user_id、open_id、union_id、email、mobile
+type ResolveUsersRequest struct {
+	Ids []*service.UserIdentifier `json:"ids" binding:"required"`
+}
+
+// 转换请求中单次最多支持的用户标识数量
+const maxResolveIds = 200
+
+// 将任意类型的用户标识转换为全部ID形式
+func resolveUsers(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ResolveUsersRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if len(req.Ids) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ids不能为空"})
+			return
+		}
+		if len(req.Ids) > maxResolveIds {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ids数量不能超过%d", maxResolveIds)})
+			return
+		}
+		for i, identifier := range req.Ids {
+			if identifier == nil || identifier.Id == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("第%d个标识缺少id", i+1)})
+				return
+			}
+			if !service.IsUserIdentifierType(identifier.IdType) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("第%d个标识的id_type不支持: %s", i+1, identifier.IdType)})
+				return
+			}
+		}
+
+		results, err := feishuService.ResolveUsers(req.Ids)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		found := 0
+		for _, result := range results {
+			if result.Found {
+				found++
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    results,
+			"count":   len(results),
+			"found":   found,
+		})
+	}
+}
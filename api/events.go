@@ -0,0 +1,52 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"oapi-sdk-go-demo/service"
+
+	"github.com/gin-gonic/gin"
+	larkevent "github.com/larksuite/oapi-sdk-go/v3/event"
+)
+
+// 接收飞书事件推送，URL 校验、验签、解密与分发由 SDK 事件分发器完成
+func handleEvent(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serveLarkRequest(c, feishuService, feishuService.EventDispatcher())
+	}
+}
+
+// 接收消息卡片回传交互，按按钮回传值中的 action_id 分发
+func handleCardAction(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serveLarkRequest(c, feishuService, feishuService.CardCallbackHandler())
+	}
+}
+
+// serveLarkRequest 将请求交给 SDK 处理器并写回处理结果
+func serveLarkRequest(c *gin.Context, feishuService *service.FeishuService, handler larkevent.IReqHandler) {
+	if !feishuService.EventSubscriptionEnabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "事件订阅未配置"})
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取请求失败: " + err.Error()})
+		return
+	}
+
+	resp := handler.Handle(c.Request.Context(), &larkevent.EventReq{
+		Header:     c.Request.Header,
+		Body:       body,
+		RequestURI: c.Request.RequestURI,
+	})
+
+	for key, values := range resp.Header {
+		for _, value := range values {
+			c.Writer.Header().Add(key, value)
+		}
+	}
+	c.Status(resp.StatusCode)
+	c.Writer.Write(resp.Body)
+}
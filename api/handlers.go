@@ -7,9 +7,11 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"oapi-sdk-go-demo/converter"
 	"oapi-sdk-go-demo/service"
 	"path/filepath"
 	"strconv"
@@ -17,6 +19,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
 )
 
 // 辅助函数
@@ -27,36 +30,142 @@ func getStringValue(s *string) string {
 	return ""
 }
 
-// 用户搜索请求结构
+// 用户搜索请求结构，phone_number 与 query 二选一
+// query 按姓名、拼音、拼音首字母、英文名或邮箱前缀在本地通讯录缓存中模糊搜索
 type SearchUserRequest struct {
-	PhoneNumber string `json:"phone_number" binding:"required"`
+	PhoneNumber string `json:"phone_number"`
+	Query       string `json:"query"`
+	Limit       int    `json:"limit"`
+}
+
+// 消息接收方，设置 reply_to_message_id 时以回复方式发送，此时可不填接收者
+// 设置 async 时消息写入发件箱由后台投递，接口立即返回发件箱ID
+type MessageTarget struct {
+	ReceiveIdType    string `json:"receive_id_type"`
+	ReceiveId        string `json:"receive_id"`
+	ReplyToMessageId string `json:"reply_to_message_id"`
+	ReplyInThread    bool   `json:"reply_in_thread"`
+	Async            bool   `json:"async"`
+	Uuid             string `json:"uuid"`
+
+	outboxId string
+}
+
+// 飞书消息去重 uuid 的最大长度
+const maxIdempotencyKeyLength = 50
+
+// validate 校验接收方参数，Idempotency-Key 请求头优先于请求体中的 uuid
+func (t *MessageTarget) validate(c *gin.Context) error {
+	if t.ReplyToMessageId == "" && (t.ReceiveIdType == "" || t.ReceiveId == "") {
+		return fmt.Errorf("receive_id_type和receive_id不能为空（或提供reply_to_message_id）")
+	}
+	if key := c.GetHeader("Idempotency-Key"); key != "" {
+		t.Uuid = key
+	}
+	if len(t.Uuid) > maxIdempotencyKeyLength {
+		return fmt.Errorf("幂等键长度不能超过%d个字符", maxIdempotencyKeyLength)
+	}
+	return nil
+}
+
+// sendOptions 转换为发送消息的可选参数
+func (t *MessageTarget) sendOptions(c *gin.Context) []service.SendOption {
+	opts := []service.SendOption{service.WithCaller(callerIdentity(c))}
+	if t.ReplyToMessageId != "" {
+		opts = append(opts, service.WithReplyTo(t.ReplyToMessageId, t.ReplyInThread))
+	}
+	if t.Uuid != "" {
+		opts = append(opts, service.WithUuid(t.Uuid))
+	}
+	if t.Async {
+		// 带幂等键的异步消息以幂等键作为发件箱ID，重复请求不会重复入队
+		t.outboxId = t.Uuid
+		if t.outboxId == "" {
+			t.outboxId = service.NewOutboxId()
+		}
+		opts = append(opts, service.WithAsync(t.outboxId))
+	}
+	return opts
+}
+
+// respondQueued 返回异步发送的入队结果
+func respondQueued(c *gin.Context, outboxId string) {
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data": gin.H{
+			"outbox_id": outboxId,
+			"status":    "queued",
+		},
+	})
+}
+
+// respondSendError 返回发送失败的结果，手机号无法解析时返回 404 并列出未解析的接收者
+func respondSendError(c *gin.Context, err error) {
+	var unresolved *service.UnresolvedRecipientError
+	if errors.As(err, &unresolved) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"unresolved": []gin.H{{
+				"receive_id_type": unresolved.ReceiveIdType,
+				"receive_id":      unresolved.ReceiveId,
+			}},
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// callerIdentity 获取调用方标识，优先使用 X-Caller 请求头，否则使用客户端IP
+func callerIdentity(c *gin.Context) string {
+	if caller := c.GetHeader("X-Caller"); caller != "" {
+		return caller
+	}
+	return c.ClientIP()
 }
 
 // 发送消息请求结构
+// Format 为空或 "text" 时按纯文本发送，为 "markdown" 时转换为富文本发送
 type SendMessageRequest struct {
-	ReceiveIdType string `json:"receive_id_type" binding:"required"`
-	ReceiveId     string `json:"receive_id" binding:"required"`
-	Content       string `json:"content" binding:"required"`
+	MessageTarget
+	Content string `json:"content" binding:"required"`
+	Format  string `json:"format"`
 }
 
 // 发送图片消息请求结构
 type SendImageMessageRequest struct {
-	ReceiveIdType string `json:"receive_id_type" binding:"required"`
-	ReceiveId     string `json:"receive_id" binding:"required"`
-	ImageKey      string `json:"image_key" binding:"required"`
+	MessageTarget
+	ImageKey string `json:"image_key" binding:"required"`
 }
 
 // 发送文件消息请求结构
 type SendFileMessageRequest struct {
-	ReceiveIdType string `json:"receive_id_type" binding:"required"`
-	ReceiveId     string `json:"receive_id" binding:"required"`
-	FileKey       string `json:"file_key" binding:"required"`
+	MessageTarget
+	FileKey string `json:"file_key" binding:"required"`
+}
+
+// 发送富文本消息请求结构
+type SendPostMessageRequest struct {
+	MessageTarget
+	Post service.PostMessage `json:"post" binding:"required"`
+}
+
+// 发送卡片消息请求结构，card 与 builder 二选一
+type SendCardMessageRequest struct {
+	MessageTarget
+	Card    json.RawMessage   `json:"card"`
+	Builder *service.CardSpec `json:"builder"`
 }
 
-// 简单文本消息推送请求结构
+// 简单文本消息推送请求结构，userid、phone、email 任填其一，设置 reply_to_message_id 时可都不填
 type SimpleMessageRequest struct {
-	UserID string `json:"userid" binding:"required"`
-	Msg    string `json:"msg" binding:"required"`
+	UserID           string `json:"userid"`
+	Phone            string `json:"phone"`
+	Email            string `json:"email"`
+	Msg              string `json:"msg" binding:"required"`
+	ReplyToMessageId string `json:"reply_to_message_id"`
+	ReplyInThread    bool   `json:"reply_in_thread"`
+	Async            bool   `json:"async"`
+	Uuid             string `json:"uuid"`
 }
 
 // 搜索用户
@@ -68,7 +177,18 @@ func searchUser(feishuService *service.FeishuService, db *sql.DB) gin.HandlerFun
 			return
 		}
 
-		users, err := feishuService.SearchUserByPhone(req.PhoneNumber)
+		if req.PhoneNumber == "" && req.Query == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "phone_number和query不能同时为空"})
+			return
+		}
+
+		var users []*service.UserInfo
+		var err error
+		if req.PhoneNumber != "" {
+			users, err = feishuService.SearchUserByPhone(req.PhoneNumber)
+		} else {
+			users, err = feishuService.SearchUsers(req.Query, req.Limit)
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -136,9 +256,29 @@ func sendMessage(feishuService *service.FeishuService) gin.HandlerFunc {
 			return
 		}
 
-		result, err := feishuService.SendTextMessage(req.ReceiveIdType, req.ReceiveId, req.Content)
+		if err := req.validate(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var result *larkim.CreateMessageRespData
+		var err error
+		switch req.Format {
+		case "", "text":
+			result, err = feishuService.SendTextMessage(req.ReceiveIdType, req.ReceiveId, req.Content, req.sendOptions(c)...)
+		case "markdown":
+			result, err = sendMarkdownMessage(feishuService, req.ReceiveIdType, req.ReceiveId, req.Content, req.sendOptions(c)...)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的format: " + req.Format})
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondSendError(c, err)
+			return
+		}
+
+		if req.Async {
+			respondQueued(c, req.outboxId)
 			return
 		}
 
@@ -149,6 +289,16 @@ func sendMessage(feishuService *service.FeishuService) gin.HandlerFunc {
 	}
 }
 
+// sendMarkdownMessage 将Markdown转换为富文本发送，转换失败时降级为纯文本
+func sendMarkdownMessage(feishuService *service.FeishuService, receiveIdType, receiveId, content string, opts ...service.SendOption) (*larkim.CreateMessageRespData, error) {
+	post, err := converter.ToPost(content)
+	if err != nil {
+		fmt.Printf("Markdown转换富文本失败，降级为纯文本发送: %v\n", err)
+		return feishuService.SendTextMessage(receiveIdType, receiveId, converter.ToPlainText(content), opts...)
+	}
+	return feishuService.SendPostMessage(receiveIdType, receiveId, post, opts...)
+}
+
 // 上传图片
 func uploadImage(feishuService *service.FeishuService, db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -221,13 +371,23 @@ func sendImageMessage(feishuService *service.FeishuService) gin.HandlerFunc {
 			return
 		}
 
+		if err := req.validate(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		fmt.Printf("发送图片消息 - 接收者类型: %s, 接收者ID: %s, 图片Key: %s\n", 
 			req.ReceiveIdType, req.ReceiveId, req.ImageKey)
 
-		result, err := feishuService.SendImageMessage(req.ReceiveIdType, req.ReceiveId, req.ImageKey)
+		result, err := feishuService.SendImageMessage(req.ReceiveIdType, req.ReceiveId, req.ImageKey, req.sendOptions(c)...)
 		if err != nil {
 			fmt.Printf("发送图片消息失败: %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondSendError(c, err)
+			return
+		}
+
+		if req.Async {
+			respondQueued(c, req.outboxId)
 			return
 		}
 
@@ -254,9 +414,19 @@ func sendFileMessage(feishuService *service.FeishuService) gin.HandlerFunc {
 			return
 		}
 
-		result, err := feishuService.SendFileMessage(req.ReceiveIdType, req.ReceiveId, req.FileKey)
+		if err := req.validate(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := feishuService.SendFileMessage(req.ReceiveIdType, req.ReceiveId, req.FileKey, req.sendOptions(c)...)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondSendError(c, err)
+			return
+		}
+
+		if req.Async {
+			respondQueued(c, req.outboxId)
 			return
 		}
 
@@ -267,6 +437,96 @@ func sendFileMessage(feishuService *service.FeishuService) gin.HandlerFunc {
 	}
 }
 
+// 发送富文本消息
+func sendPostMessage(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SendPostMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := req.validate(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := req.Post.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := feishuService.SendPostMessage(req.ReceiveIdType, req.ReceiveId, req.Post, req.sendOptions(c)...)
+		if err != nil {
+			respondSendError(c, err)
+			return
+		}
+
+		if req.Async {
+			respondQueued(c, req.outboxId)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"message_id": getStringValue(result.MessageId),
+			},
+		})
+	}
+}
+
+// 发送卡片消息
+func sendCardMessage(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SendCardMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := req.validate(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		hasCard := len(req.Card) > 0 && string(req.Card) != "null"
+		if hasCard == (req.Builder != nil) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "card和builder必须且只能提供一个"})
+			return
+		}
+
+		var result *larkim.CreateMessageRespData
+		var err error
+		if hasCard {
+			result, err = feishuService.SendRawCardMessage(req.ReceiveIdType, req.ReceiveId, string(req.Card), req.sendOptions(c)...)
+		} else {
+			card, buildErr := req.Builder.Build()
+			if buildErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": buildErr.Error()})
+				return
+			}
+			result, err = feishuService.SendCardMessage(req.ReceiveIdType, req.ReceiveId, card, req.sendOptions(c)...)
+		}
+		if err != nil {
+			respondSendError(c, err)
+			return
+		}
+
+		if req.Async {
+			respondQueued(c, req.outboxId)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"message_id": getStringValue(result.MessageId),
+			},
+		})
+	}
+}
+
 // 上传文件
 func uploadFile(feishuService *service.FeishuService, db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -446,14 +706,28 @@ func getImageURL(feishuService *service.FeishuService) gin.HandlerFunc {
 	}
 }
 
+// simpleReceiver 按 userid、phone、email 的优先级确定简单推送的接收者
+func simpleReceiver(userid, phone, email string) (string, string) {
+	switch {
+	case userid != "":
+		return "user_id", userid
+	case phone != "":
+		return service.ReceiveIdTypeMobile, phone
+	case email != "":
+		return service.ReceiveIdTypeEmail, email
+	}
+	return "user_id", ""
+}
+
 // 简单文本消息推送 - GET方式
 func sendSimpleMessageGET(feishuService *service.FeishuService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userid := c.Query("userid")
+		receiveIdType, receiveId := simpleReceiver(c.Query("userid"), c.Query("phone"), c.Query("email"))
 		msg := c.Query("msg")
+		replyTo := c.Query("reply_to_message_id")
 		
-		if userid == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "userid参数不能为空"})
+		if receiveId == "" && replyTo == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "userid、phone、email参数不能同时为空"})
 			return
 		}
 		
@@ -462,10 +736,27 @@ func sendSimpleMessageGET(feishuService *service.FeishuService) gin.HandlerFunc
 			return
 		}
 		
-		// 使用user_id作为接收者类型
-		result, err := feishuService.SendTextMessage("user_id", userid, msg)
+		target := MessageTarget{
+			ReceiveIdType:    receiveIdType,
+			ReceiveId:        receiveId,
+			ReplyToMessageId: replyTo,
+			ReplyInThread:    c.Query("reply_in_thread") == "true",
+			Async:            c.Query("async") == "true",
+			Uuid:             c.Query("uuid"),
+		}
+		if err := target.validate(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := feishuService.SendTextMessage(target.ReceiveIdType, target.ReceiveId, msg, target.sendOptions(c)...)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondSendError(c, err)
+			return
+		}
+
+		if target.Async {
+			respondQueued(c, target.outboxId)
 			return
 		}
 
@@ -486,10 +777,33 @@ func sendSimpleMessagePOST(feishuService *service.FeishuService) gin.HandlerFunc
 			return
 		}
 		
-		// 使用user_id作为接收者类型
-		result, err := feishuService.SendTextMessage("user_id", req.UserID, req.Msg)
+		receiveIdType, receiveId := simpleReceiver(req.UserID, req.Phone, req.Email)
+		if receiveId == "" && req.ReplyToMessageId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "userid、phone、email不能同时为空"})
+			return
+		}
+
+		target := MessageTarget{
+			ReceiveIdType:    receiveIdType,
+			ReceiveId:        receiveId,
+			ReplyToMessageId: req.ReplyToMessageId,
+			ReplyInThread:    req.ReplyInThread,
+			Async:            req.Async,
+			Uuid:             req.Uuid,
+		}
+		if err := target.validate(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := feishuService.SendTextMessage(target.ReceiveIdType, target.ReceiveId, req.Msg, target.sendOptions(c)...)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondSendError(c, err)
+			return
+		}
+
+		if target.Async {
+			respondQueued(c, target.outboxId)
 			return
 		}
 
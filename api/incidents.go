@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"oapi-sdk-go-demo/service"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 作战群初始成员上限，与建群接口单次拉人上限一致
+const maxWarRoomResponders = 50
+
+// 创建事故作战群
+// 支持 JSON 请求体，或 multipart 表单（payload 字段为 JSON 参数，runbook 字段为 runbook 文件）
+func createWarRoom(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var spec service.IncidentSpec
+		var runbookName string
+		var runbook io.Reader
+
+		if strings.HasPrefix(c.ContentType(), "multipart/") {
+			if err := json.Unmarshal([]byte(c.PostForm("payload")), &spec); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "payload不是有效的JSON: " + err.Error()})
+				return
+			}
+			if file, header, err := c.Request.FormFile("runbook"); err == nil {
+				defer file.Close()
+				runbookName = header.Filename
+				runbook = file
+			}
+		} else if err := c.ShouldBindJSON(&spec); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if spec.IncidentId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "incident_id不能为空"})
+			return
+		}
+		responders := len(spec.UserIds) + len(spec.Mobiles) + len(spec.Emails)
+		if responders == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user_ids、mobiles、emails不能同时为空"})
+			return
+		}
+		if responders > maxWarRoomResponders {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("响应人数量不能超过%d", maxWarRoomResponders)})
+			return
+		}
+
+		room, err := feishuService.CreateIncidentWarRoom(&spec, runbookName, runbook)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrWarRoomInProgress):
+				c.JSON(http.StatusConflict, gin.H{"error": "该事故的作战群正在创建中"})
+			case errors.Is(err, service.ErrWarRoomNotSaved):
+				// 作战群已创建但未保存，事故ID已释放，返回群信息供调用方处理已创建的群
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": room})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    room,
+		})
+	}
+}
+
+// 获取事故作战群
+func getWarRoom(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room, err := feishuService.GetIncidentWarRoom(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if room == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "作战群不存在"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    room,
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"oapi-sdk-go-demo/integrations"
+	"oapi-sdk-go-demo/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 接收 Alertmanager webhook 推送，:target 为接收者ID，receive_id_type 默认为 chat_id
+func alertmanagerWebhook(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		receiveIdType := c.DefaultQuery("receive_id_type", "chat_id")
+		receiveId := c.Param("target")
+
+		var payload service.AlertmanagerPayload
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
+			return
+		}
+		if payload.GroupKey == "" || len(payload.Alerts) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "groupKey 和 alerts 不能为空"})
+			return
+		}
+
+		notification, err := feishuService.NotifyAlertmanager(receiveIdType, receiveId, &payload)
+		if err != nil {
+			respondSendError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    notification,
+		})
+	}
+}
+
+// 接收第三方 webhook 推送（:source 为 github、gitlab、grafana 等），校验签名后转换为消息卡片
+// 按路由规则异步发送到匹配的群或用户，推送ID相同的重复推送不会重复发送
+// 没有匹配的路由时返回 unrouted，全部路由写入发件箱失败时返回 503
+func integrationWebhook(feishuService *service.FeishuService, db *sql.DB, registry *integrations.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		source := c.Param("source")
+		adapter, ok := registry.Get(source)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "未启用的集成: " + source})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "读取请求失败: " + err.Error()})
+			return
+		}
+		if err := adapter.Verify(c.Request.Header, body); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "签名校验失败"})
+			return
+		}
+
+		message, err := adapter.Convert(c.Request.Header, body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if message == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": true,
+				"data":    gin.H{"ignored": true},
+			})
+			return
+		}
+
+		routes, err := integrations.MatchRoutes(db, source, message.RouteKey)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(routes) == 0 {
+			log.Printf("No integration route matches %s %s", source, message.RouteKey)
+			c.JSON(http.StatusOK, gin.H{
+				"success": true,
+				"data": gin.H{
+					"event":      message.Event,
+					"route_key":  message.RouteKey,
+					"unrouted":   true,
+					"deliveries": []gin.H{},
+				},
+			})
+			return
+		}
+
+		deliveries := []gin.H{}
+		failed := 0
+		for _, route := range routes {
+			outboxId := integrationOutboxId(source, message.DeliveryId, route.Id)
+			opts := []service.SendOption{service.WithCaller("integration:" + source), service.WithAsync(outboxId)}
+			if message.DeliveryId != "" {
+				opts = append(opts, service.WithUuid(outboxId))
+			}
+
+			delivery := gin.H{
+				"route_id":        route.Id,
+				"receive_id_type": route.ReceiveIdType,
+				"receive_id":      route.ReceiveId,
+			}
+			if _, err := feishuService.SendCardMessage(route.ReceiveIdType, route.ReceiveId, message.Card, opts...); err != nil {
+				log.Printf("Failed to enqueue %s notification for route %d: %v", source, route.Id, err)
+				delivery["error"] = err.Error()
+				failed++
+			} else {
+				delivery["outbox_id"] = outboxId
+			}
+			deliveries = append(deliveries, delivery)
+		}
+
+		data := gin.H{
+			"event":      message.Event,
+			"route_key":  message.RouteKey,
+			"deliveries": deliveries,
+		}
+		if failed == len(routes) {
+			// 全部写入发件箱失败时返回 5xx 让来源重新推送，发件箱ID由推送ID确定，重试不会重复发送
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "通知写入发件箱失败",
+				"data":  data,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    data,
+		})
+	}
+}
+
+// integrationOutboxId 推送ID与路由规则确定的发件箱ID，来源未提供推送ID时随机生成
+func integrationOutboxId(source, deliveryId string, routeId int64) string {
+	if deliveryId == "" {
+		return service.NewOutboxId()
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d", source, deliveryId, routeId)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// 获取集成路由规则，可以通过 source 参数筛选来源
+func getIntegrationRoutes(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		routes, err := integrations.ListRoutes(db, c.Query("source"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    routes,
+		})
+	}
+}
+
+// 添加集成路由规则
+func addIntegrationRoute(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var route integrations.Route
+		if err := c.ShouldBindJSON(&route); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := integrations.AddRoute(db, &route); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    route,
+		})
+	}
+}
+
+// 删除集成路由规则
+func deleteIntegrationRoute(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则ID"})
+			return
+		}
+
+		deleted, err := integrations.DeleteRoute(db, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "路由规则不存在"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "路由规则删除成功",
+		})
+	}
+}
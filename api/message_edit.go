@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"oapi-sdk-go-demo/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 编辑消息请求结构，msg_type 为 text 时使用 content，为 post 时使用 post
+type EditMessageRequest struct {
+	MsgType string              `json:"msg_type" binding:"required"`
+	Content string              `json:"content"`
+	Post    service.PostMessage `json:"post"`
+}
+
+// 更新卡片请求结构，card 与 builder 二选一
+type UpdateCardRequest struct {
+	Card    json.RawMessage   `json:"card"`
+	Builder *service.CardSpec `json:"builder"`
+}
+
+// respondMessageError 根据错误类型返回对应的状态码
+func respondMessageError(c *gin.Context, err error) {
+	var feishuErr *service.FeishuError
+	switch {
+	case errors.Is(err, service.ErrMessageNotOwned):
+		c.JSON(http.StatusNotFound, gin.H{"error": "消息不存在、已撤回或不是由本服务发送"})
+	case errors.Is(err, service.ErrMessageNotEditable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &feishuErr):
+		// 飞书拒绝了请求，如超过可编辑或可撤回的时限，返回飞书错误码便于调用方判断
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "code": feishuErr.Code})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// 编辑已发送的文本或富文本消息
+func editMessage(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		messageId := c.Param("message_id")
+
+		var req EditMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var err error
+		switch req.MsgType {
+		case "text":
+			if req.Content == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "content不能为空"})
+				return
+			}
+			err = feishuService.EditTextMessage(messageId, req.Content)
+		case "post":
+			if err := req.Post.Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			err = feishuService.EditPostMessage(messageId, req.Post)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "仅支持编辑text和post消息"})
+			return
+		}
+		if err != nil {
+			respondMessageError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "消息已编辑",
+		})
+	}
+}
+
+// 更新已发送的卡片消息
+func updateCardMessage(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		messageId := c.Param("message_id")
+
+		var req UpdateCardRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		hasCard := len(req.Card) > 0 && string(req.Card) != "null"
+		if hasCard == (req.Builder != nil) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "card和builder必须且只能提供一个"})
+			return
+		}
+
+		var err error
+		if hasCard {
+			err = feishuService.UpdateRawCardMessage(messageId, string(req.Card))
+		} else {
+			card, buildErr := req.Builder.Build()
+			if buildErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": buildErr.Error()})
+				return
+			}
+			err = feishuService.UpdateCardMessage(messageId, card)
+		}
+		if err != nil {
+			respondMessageError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "卡片已更新",
+		})
+	}
+}
+
+// 撤回已发送的消息
+func recallMessage(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		messageId := c.Param("message_id")
+
+		if err := feishuService.RecallMessage(messageId); err != nil {
+			respondMessageError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "消息已撤回",
+		})
+	}
+}
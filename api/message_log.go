@@ -0,0 +1,149 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 查询消息发送历史
+// 支持按 receive_id、receive_id_type、msg_type、message_id、status、caller、start_time、end_time 过滤，
+// 使用 page 和 page_size 分页；时间支持 RFC3339、"2006-01-02 15:04:05" 和 "2006-01-02"，未带时区时按服务器本地时间
+func getMessageHistory(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if page < 1 {
+			page = 1
+		}
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+		if pageSize < 1 || pageSize > 100 {
+			pageSize = 20
+		}
+
+		var conditions []string
+		var args []interface{}
+		for _, field := range []string{"receive_id", "receive_id_type", "msg_type", "message_id", "status", "caller"} {
+			if value := c.Query(field); value != "" {
+				conditions = append(conditions, field+" = ?")
+				args = append(args, value)
+			}
+		}
+		if start := c.Query("start_time"); start != "" {
+			t, _, err := parseLogTime(start)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "start_time格式错误: " + err.Error()})
+				return
+			}
+			conditions = append(conditions, "created_at >= ?")
+			args = append(args, formatLogTime(t))
+		}
+		if end := c.Query("end_time"); end != "" {
+			t, dateOnly, err := parseLogTime(end)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "end_time格式错误: " + err.Error()})
+				return
+			}
+			if dateOnly {
+				// 只有日期时包含当天
+				conditions = append(conditions, "created_at < ?")
+				args = append(args, formatLogTime(t.AddDate(0, 0, 1)))
+			} else {
+				conditions = append(conditions, "created_at <= ?")
+				args = append(args, formatLogTime(t))
+			}
+		}
+
+		where := ""
+		if len(conditions) > 0 {
+			where = "WHERE " + strings.Join(conditions, " AND ")
+		}
+
+		var total int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM message_log `+where, args...).Scan(&total); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		rows, err := db.Query(`
+			SELECT id, receive_id_type, receive_id, reply_to_message_id, msg_type, content_hash,
+				message_id, status, error_code, error_msg, latency_ms, caller, created_at
+			FROM message_log `+where+`
+			ORDER BY id DESC
+			LIMIT ? OFFSET ?
+		`, append(args, pageSize, (page-1)*pageSize)...)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+
+		var history []map[string]interface{}
+		for rows.Next() {
+			var id, latencyMs int64
+			var errorCode int
+			var receiveIdType, receiveId, replyTo, msgType, contentHash, messageId, status, errorMsg, caller sql.NullString
+			var createdAt time.Time
+
+			if err := rows.Scan(&id, &receiveIdType, &receiveId, &replyTo, &msgType, &contentHash,
+				&messageId, &status, &errorCode, &errorMsg, &latencyMs, &caller, &createdAt); err != nil {
+				continue
+			}
+
+			history = append(history, map[string]interface{}{
+				"id":                  id,
+				"receive_id_type":     receiveIdType.String,
+				"receive_id":          receiveId.String,
+				"reply_to_message_id": replyTo.String,
+				"msg_type":            msgType.String,
+				"content_hash":        contentHash.String,
+				"message_id":          messageId.String,
+				"status":              status.String,
+				"error_code":          errorCode,
+				"error_msg":           errorMsg.String,
+				"latency_ms":          latencyMs,
+				"caller":              caller.String,
+				"created_at":          createdAt.Local().Format("2006-01-02 15:04:05"),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":   true,
+			"data":      history,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		})
+	}
+}
+
+// 查询条件支持的时间格式，未带时区的格式按本地时间解析
+var logTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+}
+
+// parseLogTime 解析查询条件中的时间，dateOnly 表示只有日期
+func parseLogTime(value string) (t time.Time, dateOnly bool, err error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, true, nil
+	}
+	for _, layout := range logTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, false, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("%q 不是支持的时间格式", value)
+}
+
+// formatLogTime 转换为与 SQLite CURRENT_TIMESTAMP 一致的 UTC 格式
+func formatLogTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"oapi-sdk-go-demo/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 获取发件箱中待投递的消息
+func getOutboxList(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+		rows, err := db.Query(`
+			SELECT id, receive_id_type, receive_id, msg_type, status, attempts, last_error, next_attempt_at, created_at
+			FROM message_outbox
+			ORDER BY created_at
+			LIMIT ?
+		`, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+
+		var messages []map[string]interface{}
+		for rows.Next() {
+			var id, msgType, status string
+			var receiveIdType, receiveId, lastError sql.NullString
+			var attempts int
+			var nextAttemptAt, createdAt time.Time
+
+			if err := rows.Scan(&id, &receiveIdType, &receiveId, &msgType, &status, &attempts, &lastError, &nextAttemptAt, &createdAt); err != nil {
+				continue
+			}
+
+			messages = append(messages, map[string]interface{}{
+				"id":              id,
+				"receive_id_type": receiveIdType.String,
+				"receive_id":      receiveId.String,
+				"msg_type":        msgType,
+				"status":          status,
+				"attempts":        attempts,
+				"last_error":      lastError.String,
+				"next_attempt_at": nextAttemptAt.Format("2006-01-02 15:04:05"),
+				"created_at":      createdAt.Format("2006-01-02 15:04:05"),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    messages,
+		})
+	}
+}
+
+// 获取死信列表
+func getDeadLetterList(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+		rows, err := db.Query(`
+			SELECT id, receive_id_type, receive_id, reply_to_message_id, msg_type, content, caller,
+				attempts, error_code, last_error, created_at, failed_at
+			FROM message_dead_letters
+			ORDER BY failed_at DESC
+			LIMIT ?
+		`, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+
+		var letters []map[string]interface{}
+		for rows.Next() {
+			var id, msgType, content string
+			var receiveIdType, receiveId, replyTo, caller, lastError sql.NullString
+			var attempts, errorCode int
+			var createdAt, failedAt time.Time
+
+			if err := rows.Scan(&id, &receiveIdType, &receiveId, &replyTo, &msgType, &content, &caller,
+				&attempts, &errorCode, &lastError, &createdAt, &failedAt); err != nil {
+				continue
+			}
+
+			letters = append(letters, map[string]interface{}{
+				"id":                  id,
+				"receive_id_type":     receiveIdType.String,
+				"receive_id":          receiveId.String,
+				"reply_to_message_id": replyTo.String,
+				"msg_type":            msgType,
+				"content":             content,
+				"caller":              caller.String,
+				"attempts":            attempts,
+				"error_code":          errorCode,
+				"last_error":          lastError.String,
+				"created_at":          createdAt.Format("2006-01-02 15:04:05"),
+				"failed_at":           failedAt.Format("2006-01-02 15:04:05"),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    letters,
+		})
+	}
+}
+
+// 重新投递死信
+func replayDeadLetter(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		if err := feishuService.ReplayDeadLetter(id); err != nil {
+			if errors.Is(err, service.ErrDeadLetterNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "死信不存在"})
+			} else if errors.Is(err, service.ErrDeadLetterRequeued) {
+				c.JSON(http.StatusConflict, gin.H{"error": "相同ID的消息已在发件箱中，无需重放"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"outbox_id": id,
+				"status":    "queued",
+			},
+		})
+	}
+}
+
+// 清除死信，不带ID时清除全部
+func purgeDeadLetters(feishuService *service.FeishuService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		count, err := feishuService.PurgeDeadLetters(id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if id != "" && count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "死信不存在"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"purged": count,
+			},
+		})
+	}
+}
@@ -2,31 +2,107 @@ package api
 
 import (
 	"database/sql"
+	"oapi-sdk-go-demo/integrations"
 	"oapi-sdk-go-demo/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes 设置API路由
-func SetupRoutes(router *gin.Engine, feishuService *service.FeishuService, db *sql.DB) {
+func SetupRoutes(router *gin.Engine, feishuService *service.FeishuService, db *sql.DB, integrationRegistry *integrations.Registry) {
 	apiGroup := router.Group("/api")
 	{
 		// 用户相关接口
 		userGroup := apiGroup.Group("/users")
 		{
 			userGroup.POST("/search", searchUser(feishuService, db))
+			userGroup.POST("/resolve", resolveUsers(feishuService))
 			// 搜索历史功能已停用以保护用户隐私
 		}
 
+		// 通讯录缓存同步接口
+		contactGroup := apiGroup.Group("/contacts")
+		{
+			contactGroup.GET("/sync", getContactSyncStatus(feishuService))
+			contactGroup.POST("/sync", triggerContactSync(feishuService))
+		}
+
+		// 部门相关接口
+		departmentGroup := apiGroup.Group("/departments")
+		{
+			departmentGroup.GET("/:id/users", getDepartmentUsers(feishuService))
+			departmentGroup.GET("/:id/export", exportDepartment(feishuService))
+		}
+
 		// 消息相关接口
 		messageGroup := apiGroup.Group("/messages")
 		{
 			messageGroup.POST("/send", sendMessage(feishuService))
 			messageGroup.POST("/send-image", sendImageMessage(feishuService))
 			messageGroup.POST("/send-file", sendFileMessage(feishuService))
+			messageGroup.POST("/send-post", sendPostMessage(feishuService))
+			messageGroup.POST("/send-card", sendCardMessage(feishuService))
+			messageGroup.POST("/send-template", sendTemplateMessage(feishuService, db))
+			messageGroup.POST("/batch", batchSendMessage(feishuService))
 			// 简单文本消息推送接口
 			messageGroup.GET("/send-simple", sendSimpleMessageGET(feishuService))
 			messageGroup.POST("/send-simple", sendSimpleMessagePOST(feishuService))
+			messageGroup.GET("/history", getMessageHistory(db))
+			// 异步发件箱与死信
+			messageGroup.GET("/outbox", getOutboxList(db))
+			messageGroup.GET("/dead-letters", getDeadLetterList(db))
+			messageGroup.POST("/dead-letters/:id/replay", replayDeadLetter(feishuService))
+			messageGroup.DELETE("/dead-letters/:id", purgeDeadLetters(feishuService))
+			messageGroup.DELETE("/dead-letters", purgeDeadLetters(feishuService))
+			// 编辑、更新、撤回本服务发送的消息
+			messageGroup.PUT("/:message_id", editMessage(feishuService))
+			messageGroup.PATCH("/:message_id/card", updateCardMessage(feishuService))
+			messageGroup.DELETE("/:message_id", recallMessage(feishuService))
+		}
+
+		// 群管理接口
+		chatGroup := apiGroup.Group("/chats")
+		{
+			chatGroup.POST("", createChat(feishuService))
+			chatGroup.GET("", getChatList(feishuService))
+			chatGroup.GET("/:chat_id", getChat(feishuService))
+			chatGroup.DELETE("/:chat_id", disbandChat(feishuService))
+			chatGroup.POST("/:chat_id/members", addChatMembers(feishuService))
+			chatGroup.DELETE("/:chat_id/members", removeChatMembers(feishuService))
+		}
+
+		// 事故作战群接口
+		incidentGroup := apiGroup.Group("/incidents")
+		{
+			incidentGroup.POST("/war-room", createWarRoom(feishuService))
+			incidentGroup.GET("/:id/war-room", getWarRoom(feishuService))
+		}
+
+		// 第三方告警与通知集成
+		integrationGroup := apiGroup.Group("/integrations")
+		{
+			integrationGroup.POST("/alertmanager/:target", alertmanagerWebhook(feishuService))
+			integrationGroup.POST("/webhooks/:source", integrationWebhook(feishuService, db, integrationRegistry))
+			integrationGroup.GET("/routes", getIntegrationRoutes(db))
+			integrationGroup.POST("/routes", addIntegrationRoute(db))
+			integrationGroup.DELETE("/routes/:id", deleteIntegrationRoute(db))
+		}
+
+		// 自定义机器人兼容地址管理
+		hookGroup := apiGroup.Group("/hooks")
+		{
+			hookGroup.POST("", createBotHook(feishuService))
+			hookGroup.GET("", getBotHookList(feishuService))
+			hookGroup.DELETE("/:token", deleteBotHook(feishuService))
+		}
+
+		// 消息模板相关接口
+		templateGroup := apiGroup.Group("/templates")
+		{
+			templateGroup.POST("", saveTemplate(db))
+			templateGroup.GET("", getTemplateList(db))
+			templateGroup.GET("/:name", getTemplate(db))
+			templateGroup.DELETE("/:name", deleteTemplate(db))
 		}
 
 		// 文件上传相关接口
@@ -48,6 +124,17 @@ fileGroup.GET("/:resource_key", getFileInfo(db))
 		})
 	}
 
+	// 飞书事件推送与卡片回传交互
+	webhookGroup := router.Group("/webhook")
+	{
+		webhookGroup.POST("/event", handleEvent(feishuService))
+		webhookGroup.POST("/card", handleCardAction(feishuService))
+	}
+
+	// 自定义机器人兼容地址，同时提供与飞书一致的路径，调用方只需替换域名
+	router.POST("/hook/:token", botHookWebhook(feishuService))
+	router.POST("/open-apis/bot/v2/hook/:token", botHookWebhook(feishuService))
+
 	// Web页面路由
 	router.GET("/", func(c *gin.Context) {
 		c.File("./static/index.html")
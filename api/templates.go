@@ -0,0 +1,213 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"oapi-sdk-go-demo/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 保存消息模板请求结构
+type SaveTemplateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	MsgType     string `json:"msg_type" binding:"required"`
+	Content     string `json:"content" binding:"required"`
+	Description string `json:"description"`
+}
+
+// 模板消息发送请求结构
+type SendTemplateMessageRequest struct {
+	MessageTarget
+	TemplateName string                 `json:"template_name" binding:"required"`
+	Variables    map[string]interface{} `json:"variables"`
+}
+
+// 保存消息模板（同名模板会被覆盖）
+func saveTemplate(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SaveTemplateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := service.ValidateTemplate(req.MsgType, req.Content); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		_, err := db.Exec(`
+			INSERT INTO message_templates (name, msg_type, content, description)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(name) DO UPDATE SET
+				msg_type = excluded.msg_type,
+				content = excluded.content,
+				description = excluded.description,
+				updated_at = CURRENT_TIMESTAMP
+		`, req.Name, req.MsgType, req.Content, req.Description)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "模板保存成功",
+		})
+	}
+}
+
+// 获取模板列表
+func getTemplateList(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rows, err := db.Query(`
+			SELECT name, msg_type, description, updated_at
+			FROM message_templates
+			ORDER BY name
+		`)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+
+		var templates []map[string]interface{}
+		for rows.Next() {
+			var name, msgType string
+			var description sql.NullString
+			var updatedAt time.Time
+
+			if err := rows.Scan(&name, &msgType, &description, &updatedAt); err != nil {
+				continue
+			}
+
+			templates = append(templates, map[string]interface{}{
+				"name":        name,
+				"msg_type":    msgType,
+				"description": description.String,
+				"updated_at":  updatedAt.Format("2006-01-02 15:04:05"),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    templates,
+		})
+	}
+}
+
+// 获取模板详情
+func getTemplate(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+
+		var msgType, content string
+		var description sql.NullString
+		var createdAt, updatedAt time.Time
+
+		err := db.QueryRow(`
+			SELECT msg_type, content, description, created_at, updated_at
+			FROM message_templates
+			WHERE name = ?
+		`, name).Scan(&msgType, &content, &description, &createdAt, &updatedAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "模板不存在"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": map[string]interface{}{
+				"name":        name,
+				"msg_type":    msgType,
+				"content":     content,
+				"description": description.String,
+				"created_at":  createdAt.Format("2006-01-02 15:04:05"),
+				"updated_at":  updatedAt.Format("2006-01-02 15:04:05"),
+			},
+		})
+	}
+}
+
+// 删除模板
+func deleteTemplate(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+
+		result, err := db.Exec(`DELETE FROM message_templates WHERE name = ?`, name)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "模板不存在"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "模板已删除",
+		})
+	}
+}
+
+// 使用模板发送消息
+func sendTemplateMessage(feishuService *service.FeishuService, db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SendTemplateMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := req.validate(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var msgType, content string
+		err := db.QueryRow(`
+			SELECT msg_type, content FROM message_templates WHERE name = ?
+		`, req.TemplateName).Scan(&msgType, &content)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "模板不存在: " + req.TemplateName})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		result, err := feishuService.SendTemplateMessage(req.ReceiveIdType, req.ReceiveId, msgType, content, req.Variables, req.sendOptions(c)...)
+		if err != nil {
+			var templateErr *service.TemplateError
+			if errors.As(err, &templateErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			respondSendError(c, err)
+			return
+		}
+
+		if req.Async {
+			respondQueued(c, req.outboxId)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"message_id": getStringValue(result.MessageId),
+				"msg_type":   msgType,
+			},
+		})
+	}
+}
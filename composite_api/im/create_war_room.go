@@ -0,0 +1,246 @@
+/*
+ 创建事故作战群，使用到以下OpenAPI：
+ 1. [通过手机号或邮箱获取用户 ID](https://open.feishu.cn/document/server-docs/contact-v3/user/batch_get_id)
+ 2. [上传文件](https://open.feishu.cn/document/server-docs/im-v1/file/create)
+ 3. [创建群](https://open.feishu.cn/document/server-docs/group/chat/create)
+ 4. [发送消息](https://open.feishu.cn/document/server-docs/im-v1/message/create)
+ 5. [Pin 消息](https://open.feishu.cn/document/server-docs/im-v1/pin/create)
+ 6. [获取群分享链接](https://open.feishu.cn/document/server-docs/group/chat/link)
+ 7. [解散群](https://open.feishu.cn/document/server-docs/group/chat/delete)
+
+ 任意一步失败时解散已创建的群，不留下不完整的作战群。
+*/
+
+package im
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/larksuite/oapi-sdk-go/v3"
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	"github.com/larksuite/oapi-sdk-go/v3/service/contact/v3"
+	"github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+type CreateWarRoomRequest struct {
+	ChatName        string
+	ChatDescription string
+	OwnerUserId     string   // 群主的 user_id，为空时机器人为群主
+	UserIds         []string // 响应人的 user_id，与手机号、邮箱解析出的用户合计不超过 50 人
+	Mobiles         []string // 响应人的手机号
+	Emails          []string // 响应人的邮箱
+	Card            string   // 事故卡片的 JSON
+	RunbookFileName string   // 为空时不发送 runbook 文件
+	RunbookFile     io.Reader
+}
+
+type CreateWarRoomResponse struct {
+	*larkcore.CodeError
+	ChatId              string
+	CardMessageId       string
+	RunbookMessageId    string
+	ShareLink           string
+	UnresolvedMobiles   []string // 未找到用户的手机号
+	UnresolvedEmails    []string // 未找到用户的邮箱
+	CreateChatResponse  *larkim.CreateChatRespData
+	CreateFileResponse  *larkim.CreateFileRespData
+	CardMessageResponse *larkim.CreateMessageRespData
+}
+
+// CreateWarRoom 创建事故作战群，拉入响应人，发送并置顶事故卡片，发送 runbook 文件，返回群分享链接
+func CreateWarRoom(client *lark.Client, request *CreateWarRoomRequest) (*CreateWarRoomResponse, error) {
+	response := &CreateWarRoomResponse{}
+
+	// 通过手机号或邮箱获取用户 ID
+	userIds := append([]string{}, request.UserIds...)
+	if len(request.Mobiles) > 0 || len(request.Emails) > 0 {
+		batchGetIdReq := larkcontact.NewBatchGetIdUserReqBuilder().
+			UserIdType("user_id").
+			Body(larkcontact.NewBatchGetIdUserReqBodyBuilder().
+				Mobiles(request.Mobiles).
+				Emails(request.Emails).
+				Build()).
+			Build()
+
+		batchGetIdResp, err := client.Contact.User.BatchGetId(context.Background(), batchGetIdReq)
+		if err != nil {
+			return nil, err
+		}
+		if !batchGetIdResp.Success() {
+			fmt.Printf("client.Contact.User.BatchGetId failed, code: %d, msg: %s, log_id: %s\n",
+				batchGetIdResp.Code, batchGetIdResp.Msg, batchGetIdResp.RequestId())
+			return nil, batchGetIdResp.CodeError
+		}
+
+		for _, user := range batchGetIdResp.Data.UserList {
+			switch {
+			case user.UserId != nil && *user.UserId != "":
+				userIds = append(userIds, *user.UserId)
+			case user.Mobile != nil:
+				response.UnresolvedMobiles = append(response.UnresolvedMobiles, *user.Mobile)
+			case user.Email != nil:
+				response.UnresolvedEmails = append(response.UnresolvedEmails, *user.Email)
+			}
+		}
+	}
+
+	// 上传 runbook 文件，放在建群之前以减少需要回滚的操作
+	if request.RunbookFileName != "" && request.RunbookFile != nil {
+		createFileReq := larkim.NewCreateFileReqBuilder().
+			Body(larkim.NewCreateFileReqBodyBuilder().
+				FileType("stream").
+				FileName(request.RunbookFileName).
+				File(request.RunbookFile).
+				Build()).
+			Build()
+		createFileResp, err := client.Im.File.Create(context.Background(), createFileReq)
+		if err != nil {
+			return nil, err
+		}
+		if !createFileResp.Success() {
+			fmt.Printf("client.Im.File.Create failed, code: %d, msg: %s, log_id: %s\n",
+				createFileResp.Code, createFileResp.Msg, createFileResp.RequestId())
+			return nil, createFileResp.CodeError
+		}
+		response.CreateFileResponse = createFileResp.Data
+	}
+
+	// 创建群
+	createChatBody := larkim.NewCreateChatReqBodyBuilder().
+		Name(request.ChatName).
+		Description(request.ChatDescription).
+		ChatMode("group").
+		ChatType("private").
+		UserIdList(userIds)
+	if request.OwnerUserId != "" {
+		createChatBody.OwnerId(request.OwnerUserId)
+	}
+	createChatReq := larkim.NewCreateChatReqBuilder().
+		UserIdType("user_id").
+		SetBotManager(true).
+		Body(createChatBody.Build()).
+		Build()
+
+	createChatResp, err := client.Im.Chat.Create(context.Background(), createChatReq)
+	if err != nil {
+		return nil, err
+	}
+	if !createChatResp.Success() {
+		fmt.Printf("client.Im.Chat.Create failed, code: %d, msg: %s, log_id: %s\n",
+			createChatResp.Code, createChatResp.Msg, createChatResp.RequestId())
+		return nil, createChatResp.CodeError
+	}
+	response.CreateChatResponse = createChatResp.Data
+	response.ChatId = *createChatResp.Data.ChatId
+
+	// 后续步骤失败时解散群
+	if err := setupWarRoom(client, request, response); err != nil {
+		deleteChatReq := larkim.NewDeleteChatReqBuilder().
+			ChatId(response.ChatId).
+			Build()
+		deleteChatResp, deleteErr := client.Im.Chat.Delete(context.Background(), deleteChatReq)
+		if deleteErr != nil {
+			fmt.Printf("client.Im.Chat.Delete failed, chat_id: %s, err: %v\n", response.ChatId, deleteErr)
+		} else if !deleteChatResp.Success() {
+			fmt.Printf("client.Im.Chat.Delete failed, code: %d, msg: %s, log_id: %s\n",
+				deleteChatResp.Code, deleteChatResp.Msg, deleteChatResp.RequestId())
+		}
+		return nil, err
+	}
+
+	// 返回结果
+	response.CodeError = &larkcore.CodeError{
+		Code: 0,
+		Msg:  "success",
+	}
+	return response, nil
+}
+
+// setupWarRoom 在已创建的群中发送并置顶事故卡片，发送 runbook 文件，获取群分享链接
+func setupWarRoom(client *lark.Client, request *CreateWarRoomRequest, response *CreateWarRoomResponse) error {
+	// 发送事故卡片
+	createMessageReq := larkim.NewCreateMessageReqBuilder().
+		ReceiveIdType("chat_id").
+		Body(larkim.NewCreateMessageReqBodyBuilder().
+			ReceiveId(response.ChatId).
+			MsgType("interactive").
+			Content(request.Card).
+			Build()).
+		Build()
+	createMessageResp, err := client.Im.Message.Create(context.Background(), createMessageReq)
+	if err != nil {
+		return err
+	}
+	if !createMessageResp.Success() {
+		fmt.Printf("client.Im.Message.Create failed, code: %d, msg: %s, log_id: %s\n",
+			createMessageResp.Code, createMessageResp.Msg, createMessageResp.RequestId())
+		return createMessageResp.CodeError
+	}
+	response.CardMessageResponse = createMessageResp.Data
+	response.CardMessageId = *createMessageResp.Data.MessageId
+
+	// 置顶事故卡片
+	createPinReq := larkim.NewCreatePinReqBuilder().
+		Body(larkim.NewCreatePinReqBodyBuilder().
+			MessageId(response.CardMessageId).
+			Build()).
+		Build()
+	createPinResp, err := client.Im.Pin.Create(context.Background(), createPinReq)
+	if err != nil {
+		return err
+	}
+	if !createPinResp.Success() {
+		fmt.Printf("client.Im.Pin.Create failed, code: %d, msg: %s, log_id: %s\n",
+			createPinResp.Code, createPinResp.Msg, createPinResp.RequestId())
+		return createPinResp.CodeError
+	}
+
+	// 发送 runbook 文件
+	if response.CreateFileResponse != nil {
+		bs, err := json.Marshal(response.CreateFileResponse)
+		if err != nil {
+			return err
+		}
+		fileMessageReq := larkim.NewCreateMessageReqBuilder().
+			ReceiveIdType("chat_id").
+			Body(larkim.NewCreateMessageReqBodyBuilder().
+				ReceiveId(response.ChatId).
+				MsgType("file").
+				Content(string(bs)).
+				Build()).
+			Build()
+		fileMessageResp, err := client.Im.Message.Create(context.Background(), fileMessageReq)
+		if err != nil {
+			return err
+		}
+		if !fileMessageResp.Success() {
+			fmt.Printf("client.Im.Message.Create failed, code: %d, msg: %s, log_id: %s\n",
+				fileMessageResp.Code, fileMessageResp.Msg, fileMessageResp.RequestId())
+			return fileMessageResp.CodeError
+		}
+		response.RunbookMessageId = *fileMessageResp.Data.MessageId
+	}
+
+	// 获取群分享链接
+	linkChatReq := larkim.NewLinkChatReqBuilder().
+		ChatId(response.ChatId).
+		Body(larkim.NewLinkChatReqBodyBuilder().
+			ValidityPeriod("permanently").
+			Build()).
+		Build()
+	linkChatResp, err := client.Im.Chat.Link(context.Background(), linkChatReq)
+	if err != nil {
+		return err
+	}
+	if !linkChatResp.Success() {
+		fmt.Printf("client.Im.Chat.Link failed, code: %d, msg: %s, log_id: %s\n",
+			linkChatResp.Code, linkChatResp.Msg, linkChatResp.RequestId())
+		return linkChatResp.CodeError
+	}
+	response.ShareLink = *linkChatResp.Data.ShareLink
+
+	return nil
+}
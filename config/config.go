@@ -1,12 +1,37 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"time"
+)
 
 type Config struct {
 	Port         string
 	DatabasePath string
 	AppID        string
 	AppSecret    string
+
+	// 发件箱异步投递配置
+	OutboxWorkers     int
+	OutboxMaxAttempts int
+
+	// 通讯录同步配置，同步间隔为 0 时不启动定时同步
+	ContactSyncInterval     time.Duration
+	ContactRootDepartmentId string
+
+	// 事件订阅配置，与开发者后台「事件与回调」中的配置一致
+	EventVerificationToken string
+	EventEncryptKey        string
+	// 事件接收方式：webhook 由飞书推送到回调地址，websocket 通过长连接接收，无需公网可访问
+	EventMode string
+	// 长连接获取接入地址使用的域名，默认为飞书开放平台，可指向本地模拟服务
+	EventWebSocketDomain string
+
+	// 第三方 webhook 集成的签名密钥，未配置的集成不接收推送
+	GitHubWebhookSecret  string
+	GitLabWebhookToken   string
+	GrafanaWebhookSecret string
 }
 
 func LoadConfig() *Config {
@@ -17,6 +42,17 @@ func LoadConfig() *Config {
 	cfg.DatabasePath = getEnvOrDefault("DATABASE_PATH", "./data/feishu_api.db")
 	cfg.AppID = os.Getenv("APP_ID")         // 必须通过环境变量设置
 	cfg.AppSecret = os.Getenv("APP_SECRET") // 必须通过环境变量设置
+	cfg.OutboxWorkers = getEnvIntOrDefault("OUTBOX_WORKERS", 4)
+	cfg.OutboxMaxAttempts = getEnvIntOrDefault("OUTBOX_MAX_ATTEMPTS", 5)
+	cfg.ContactSyncInterval = time.Duration(getEnvIntOrDefault("CONTACT_SYNC_INTERVAL_MINUTES", 0)) * time.Minute
+	cfg.ContactRootDepartmentId = getEnvOrDefault("CONTACT_ROOT_DEPARTMENT_ID", "0")
+	cfg.EventVerificationToken = os.Getenv("EVENT_VERIFICATION_TOKEN")
+	cfg.EventEncryptKey = os.Getenv("EVENT_ENCRYPT_KEY")
+	cfg.EventMode = getEnvOrDefault("EVENT_MODE", "webhook")
+	cfg.EventWebSocketDomain = os.Getenv("EVENT_WS_DOMAIN")
+	cfg.GitHubWebhookSecret = os.Getenv("GITHUB_WEBHOOK_SECRET")
+	cfg.GitLabWebhookToken = os.Getenv("GITLAB_WEBHOOK_TOKEN")
+	cfg.GrafanaWebhookSecret = os.Getenv("GRAFANA_WEBHOOK_SECRET")
 
 	return cfg
 }
@@ -27,3 +63,12 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,309 @@
+/*
+ Markdown 转换器，将 CommonMark 文本转换为飞书消息格式：
+ 1. 富文本(post)消息结构
+ 2. 消息卡片 markdown 元素
+ 3. 纯文本（用于兜底）
+*/
+
+package converter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 块级元素类型
+const (
+	blockParagraph = iota
+	blockHeading
+	blockList
+	blockCode
+	blockTable
+	blockQuote
+	blockRule
+)
+
+// block 解析后的块级元素
+type block struct {
+	kind     int
+	level    int        // 标题级别
+	ordered  bool       // 是否为有序列表
+	start    int        // 有序列表起始序号
+	lines    []string   // 段落、列表项、引用的文本行
+	language string     // 代码块语言
+	code     string     // 代码块内容
+	rows     [][]string // 表格行，第一行为表头
+}
+
+var (
+	headingRe    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletRe     = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedRe    = regexp.MustCompile(`^\s*(\d+)[.)]\s+(.*)$`)
+	fenceRe      = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([\\w+#.-]*)")
+	ruleRe       = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	tableSepRe   = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	quoteRe      = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	taskPrefixRe = regexp.MustCompile(`^\[([ xX])\]\s+`)
+)
+
+// parseBlocks 将 Markdown 文本拆分为块级元素
+func parseBlocks(md string) []*block {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	var blocks []*block
+	var current *block
+
+	flush := func() {
+		if current != nil {
+			blocks = append(blocks, current)
+			current = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		// 代码块
+		if m := fenceRe.FindStringSubmatch(line); m != nil {
+			flush()
+			fence := m[1]
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, &block{kind: blockCode, language: m[2], code: strings.Join(code, "\n")})
+			continue
+		}
+
+		if trimmed == "" {
+			flush()
+			continue
+		}
+
+		// 分割线需要先于列表判断，避免 "---" "* * *" 被识别为列表
+		if ruleRe.MatchString(line) {
+			flush()
+			blocks = append(blocks, &block{kind: blockRule})
+			continue
+		}
+
+		if m := headingRe.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			blocks = append(blocks, &block{kind: blockHeading, level: len(m[1]), lines: []string{m[2]}})
+			continue
+		}
+
+		// 表格：当前行包含竖线且下一行为分隔行
+		if strings.Contains(trimmed, "|") && i+1 < len(lines) && tableSepRe.MatchString(lines[i+1]) {
+			flush()
+			table := &block{kind: blockTable, rows: [][]string{splitTableRow(trimmed)}}
+			for i += 2; i < len(lines); i++ {
+				row := strings.TrimSpace(lines[i])
+				if row == "" || !strings.Contains(row, "|") {
+					i--
+					break
+				}
+				table.rows = append(table.rows, splitTableRow(row))
+			}
+			blocks = append(blocks, table)
+			continue
+		}
+
+		if m := quoteRe.FindStringSubmatch(line); m != nil {
+			if current == nil || current.kind != blockQuote {
+				flush()
+				current = &block{kind: blockQuote}
+			}
+			current.lines = append(current.lines, m[1])
+			continue
+		}
+
+		if m := bulletRe.FindStringSubmatch(line); m != nil {
+			if current == nil || current.kind != blockList || current.ordered {
+				flush()
+				current = &block{kind: blockList}
+			}
+			current.lines = append(current.lines, listItemText(m[1]))
+			continue
+		}
+
+		if m := orderedRe.FindStringSubmatch(line); m != nil {
+			if current == nil || current.kind != blockList || !current.ordered {
+				flush()
+				start, _ := strconv.Atoi(m[1])
+				current = &block{kind: blockList, ordered: true, start: start}
+			}
+			current.lines = append(current.lines, m[2])
+			continue
+		}
+
+		// 列表项的缩进续行
+		if current != nil && current.kind == blockList && (strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t")) {
+			last := len(current.lines) - 1
+			current.lines[last] += " " + trimmed
+			continue
+		}
+
+		if current == nil || current.kind != blockParagraph {
+			flush()
+			current = &block{kind: blockParagraph}
+		}
+		current.lines = append(current.lines, trimmed)
+	}
+	flush()
+
+	return blocks
+}
+
+// listItemText 将任务列表的 [ ]/[x] 前缀转换为符号
+func listItemText(text string) string {
+	if m := taskPrefixRe.FindStringSubmatch(text); m != nil {
+		if m[1] == " " {
+			return "☐ " + text[len(m[0]):]
+		}
+		return "☑ " + text[len(m[0]):]
+	}
+	return text
+}
+
+// splitTableRow 拆分表格行的单元格
+func splitTableRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	row = strings.TrimSuffix(row, "|")
+	cells := strings.Split(row, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// inline 行内文本片段
+type inline struct {
+	text   string
+	bold   bool
+	italic bool
+	strike bool
+	code   bool
+	href   string
+}
+
+// parseInline 解析行内的粗体、斜体、删除线、行内代码和链接
+func parseInline(text string) []*inline {
+	return parseInlineStyled(text, inline{})
+}
+
+func parseInlineStyled(text string, style inline) []*inline {
+	var result []*inline
+	var plain strings.Builder
+
+	emit := func(seg *inline) {
+		if plain.Len() > 0 {
+			p := style
+			p.text = plain.String()
+			result = append(result, &p)
+			plain.Reset()
+		}
+		if seg != nil {
+			result = append(result, seg)
+		}
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+
+		// 转义字符
+		if rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_~[]()#+-.!|>", rune(rest[1])) {
+			plain.WriteByte(rest[1])
+			i += 2
+			continue
+		}
+
+		// 行内代码
+		if rest[0] == '`' {
+			if end := strings.Index(rest[1:], "`"); end >= 0 {
+				seg := style
+				seg.text = rest[1 : end+1]
+				seg.code = true
+				emit(&seg)
+				i += end + 2
+				continue
+			}
+		}
+
+		// 链接 [text](url)
+		if rest[0] == '[' {
+			if closeText := strings.Index(rest, "]("); closeText > 0 {
+				if closeURL := strings.Index(rest[closeText:], ")"); closeURL > 0 {
+					label := rest[1:closeText]
+					href := rest[closeText+2 : closeText+closeURL]
+					emit(nil)
+					linkStyle := style
+					linkStyle.href = href
+					result = append(result, parseInlineStyled(label, linkStyle)...)
+					i += closeText + closeURL + 1
+					continue
+				}
+			}
+		}
+
+		// 强调标记
+		if marker, ok := emphasisMarker(rest); ok && !(marker[0] == '_' && i > 0 && isWordChar(text[i-1])) {
+			if end := strings.Index(rest[len(marker):], marker); end > 0 {
+				inner := rest[len(marker) : len(marker)+end]
+				emit(nil)
+				innerStyle := style
+				switch marker {
+				case "***", "___":
+					innerStyle.bold, innerStyle.italic = true, true
+				case "**", "__":
+					innerStyle.bold = true
+				case "~~":
+					innerStyle.strike = true
+				default:
+					innerStyle.italic = true
+				}
+				result = append(result, parseInlineStyled(inner, innerStyle)...)
+				i += len(marker)*2 + end
+				continue
+			}
+		}
+
+		plain.WriteByte(rest[0])
+		i++
+	}
+	emit(nil)
+
+	return result
+}
+
+// emphasisMarker 返回文本开头的强调标记
+func emphasisMarker(s string) (string, bool) {
+	for _, marker := range []string{"***", "___", "**", "__", "~~", "*", "_"} {
+		if strings.HasPrefix(s, marker) && len(s) > len(marker) && s[len(marker)] != ' ' {
+			return marker, true
+		}
+	}
+	return "", false
+}
+
+// isWordChar 判断是否为单词字符，下划线在单词内部不作为强调标记
+func isWordChar(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// plainInline 去除行内标记后的纯文本，链接保留地址
+func plainInline(text string) string {
+	var sb strings.Builder
+	for _, seg := range parseInline(text) {
+		sb.WriteString(seg.text)
+		if seg.href != "" && seg.href != seg.text {
+			sb.WriteString(" (" + seg.href + ")")
+		}
+	}
+	return sb.String()
+}
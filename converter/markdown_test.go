@@ -0,0 +1,157 @@
+package converter
+
+import (
+	"encoding/json"
+	"oapi-sdk-go-demo/service"
+	"testing"
+)
+
+func TestToPost(t *testing.T) {
+	tests := []struct {
+		name  string
+		md    string
+		title string
+		want  string // 富文本 content 的 JSON
+	}{
+		{
+			name:  "headings",
+			md:    "# 标题\n## 小节",
+			title: "标题",
+			want:  `[[{"tag":"text","text":"小节","style":["bold"]}]]`,
+		},
+		{
+			name: "bullet list",
+			md:   "- 第一项\n- 第二项",
+			want: `[[{"tag":"text","text":"• "},{"tag":"text","text":"第一项"}],[{"tag":"text","text":"• "},{"tag":"text","text":"第二项"}]]`,
+		},
+		{
+			name: "ordered list",
+			md:   "3. 三\n4. 四",
+			want: `[[{"tag":"text","text":"3. "},{"tag":"text","text":"三"}],[{"tag":"text","text":"4. "},{"tag":"text","text":"四"}]]`,
+		},
+		{
+			name: "task list",
+			md:   "- [ ] 待办\n- [x] 完成",
+			want: `[[{"tag":"text","text":"• "},{"tag":"text","text":"☐ 待办"}],[{"tag":"text","text":"• "},{"tag":"text","text":"☑ 完成"}]]`,
+		},
+		{
+			name: "emphasis",
+			md:   "**粗体** *斜体* ~~删除~~ ***粗斜***",
+			want: `[[{"tag":"text","text":"粗体","style":["bold"]},{"tag":"text","text":" "},` +
+				`{"tag":"text","text":"斜体","style":["italic"]},{"tag":"text","text":" "},` +
+				`{"tag":"text","text":"删除","style":["lineThrough"]},{"tag":"text","text":" "},` +
+				`{"tag":"text","text":"粗斜","style":["bold","italic"]}]]`,
+		},
+		{
+			name: "underscore inside word",
+			md:   "snake_case_name",
+			want: `[[{"tag":"text","text":"snake_case_name"}]]`,
+		},
+		{
+			name: "links",
+			md:   "见 [文档](https://example.com) 和 [**重点**](https://example.com/a)",
+			want: `[[{"tag":"text","text":"见 "},{"tag":"a","text":"文档","href":"https://example.com"},` +
+				`{"tag":"text","text":" 和 "},{"tag":"a","text":"重点","href":"https://example.com/a","style":["bold"]}]]`,
+		},
+		{
+			name: "fenced code",
+			md:   "```go\nfmt.Println(1)\n```",
+			want: `[[{"tag":"code_block","text":"fmt.Println(1)","language":"go"}]]`,
+		},
+		{
+			name: "table",
+			md:   "| 服务 | 状态 |\n| --- | --- |\n| api | **正常** |",
+			want: `[[{"tag":"text","text":"服务","style":["bold"]},{"tag":"text","text":" | "},{"tag":"text","text":"状态","style":["bold"]}],` +
+				`[{"tag":"text","text":"api"},{"tag":"text","text":" | "},{"tag":"text","text":"正常","style":["bold"]}]]`,
+		},
+		{
+			name: "inline code",
+			md:   "运行 `make build` 构建",
+			want: `[[{"tag":"text","text":"运行 "},{"tag":"text","text":"` + "`make build`" + `"},{"tag":"text","text":" 构建"}]]`,
+		},
+		{
+			name: "quote and rule",
+			md:   "> 引用\n\n---",
+			want: `[[{"tag":"text","text":"┃ "},{"tag":"text","text":"引用","style":["italic"]}],[{"tag":"hr"}]]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post, err := ToPost(tt.md)
+			if err != nil {
+				t.Fatalf("ToPost() error = %v", err)
+			}
+			content := post[service.PostLangZhCN]
+			if content.Title != tt.title {
+				t.Errorf("title = %q, want %q", content.Title, tt.title)
+			}
+			got, _ := json.Marshal(content.Content)
+			if string(got) != tt.want {
+				t.Errorf("content =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToPostEmpty(t *testing.T) {
+	if _, err := ToPost("\n\n"); err == nil {
+		t.Error("ToPost() of empty markdown should fail")
+	}
+}
+
+func TestToCardMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			name: "headings",
+			md:   "# 标题\n### 小节",
+			want: "**标题**\n\n**小节**",
+		},
+		{
+			name: "lists",
+			md:   "- 一\n- [x] 二\n\n1. 甲\n2. 乙",
+			want: "• 一\n• ☑ 二\n\n1. 甲\n2. 乙",
+		},
+		{
+			name: "emphasis",
+			md:   "__粗体__ _斜体_ ~~删除~~",
+			want: "**粗体** *斜体* ~~删除~~",
+		},
+		{
+			name: "links",
+			md:   "[**文档**](https://example.com)",
+			want: "[**文档**](https://example.com)",
+		},
+		{
+			name: "fenced code",
+			md:   "~~~sh\necho hi\n~~~",
+			want: "```sh\necho hi\n```",
+		},
+		{
+			name: "table",
+			md:   "| a | b |\n|:-|-:|\n| 1 | `2` |",
+			want: "**a** | **b**\n1 | `2`",
+		},
+		{
+			name: "inline code",
+			md:   "运行 `make build` 构建",
+			want: "运行 `make build` 构建",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToCardMarkdown(tt.md)
+			if got.Tag != "markdown" {
+				t.Errorf("tag = %q, want markdown", got.Tag)
+			}
+			if got.Content != tt.want {
+				t.Errorf("content =\n%s\nwant\n%s", got.Content, tt.want)
+			}
+		})
+	}
+}
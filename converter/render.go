@@ -0,0 +1,244 @@
+package converter
+
+import (
+	"fmt"
+	"oapi-sdk-go-demo/service"
+	"strings"
+)
+
+// ToPost 将 Markdown 转换为飞书富文本消息，开头的一级标题作为消息标题
+func ToPost(md string) (service.PostMessage, error) {
+	blocks := parseBlocks(md)
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("markdown content is empty")
+	}
+
+	content := &service.PostContent{}
+	if blocks[0].kind == blockHeading && blocks[0].level == 1 {
+		content.Title = plainInline(blocks[0].lines[0])
+		blocks = blocks[1:]
+	}
+
+	for _, b := range blocks {
+		switch b.kind {
+		case blockHeading:
+			content.Content = append(content.Content, postInline(b.lines[0], "bold"))
+		case blockParagraph:
+			for _, line := range b.lines {
+				content.Content = append(content.Content, postInline(line))
+			}
+		case blockList:
+			for i, item := range b.lines {
+				paragraph := []*service.PostElement{service.PostText(listBullet(b, i))}
+				content.Content = append(content.Content, append(paragraph, postInline(item)...))
+			}
+		case blockQuote:
+			for _, line := range b.lines {
+				paragraph := []*service.PostElement{service.PostText("┃ ")}
+				content.Content = append(content.Content, append(paragraph, postInline(line, "italic")...))
+			}
+		case blockCode:
+			content.Content = append(content.Content, []*service.PostElement{
+				service.PostCodeBlock(b.language, b.code),
+			})
+		case blockTable:
+			for i, row := range b.rows {
+				var paragraph []*service.PostElement
+				for j, cell := range row {
+					if j > 0 {
+						paragraph = append(paragraph, service.PostText(" | "))
+					}
+					if i == 0 {
+						paragraph = append(paragraph, postInline(cell, "bold")...)
+					} else {
+						paragraph = append(paragraph, postInline(cell)...)
+					}
+				}
+				content.Content = append(content.Content, paragraph)
+			}
+		case blockRule:
+			content.Content = append(content.Content, []*service.PostElement{service.PostDivider()})
+		}
+	}
+
+	post := service.PostMessage{service.PostLangZhCN: content}
+	if err := post.Validate(); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// postInline 将行内 Markdown 转换为富文本元素，extraStyle 会附加到每个元素上
+func postInline(text string, extraStyle ...string) []*service.PostElement {
+	var elements []*service.PostElement
+	for _, seg := range parseInline(text) {
+		style := append([]string{}, extraStyle...)
+		if seg.bold {
+			style = append(style, "bold")
+		}
+		if seg.italic {
+			style = append(style, "italic")
+		}
+		if seg.strike {
+			style = append(style, "lineThrough")
+		}
+
+		// 富文本没有行内代码样式，保留反引号以区分代码
+		text := seg.text
+		if seg.code {
+			text = "`" + text + "`"
+		}
+
+		var elem *service.PostElement
+		if seg.href != "" {
+			elem = service.PostLink(text, seg.href)
+			if len(style) > 0 {
+				elem.Style = style
+			}
+		} else {
+			elem = service.PostText(text, style...)
+		}
+		elements = append(elements, elem)
+	}
+	if len(elements) == 0 {
+		elements = append(elements, service.PostText(""))
+	}
+	return elements
+}
+
+// ToCardMarkdown 将 Markdown 转换为卡片 markdown 元素
+// 卡片不支持标题和表格语法，标题转换为粗体行，表格转换为以竖线分隔的行
+func ToCardMarkdown(md string) *service.CardMarkdown {
+	var lines []string
+	for _, b := range parseBlocks(md) {
+		switch b.kind {
+		case blockHeading:
+			lines = append(lines, "**"+cardInline(b.lines[0])+"**")
+		case blockParagraph:
+			for _, line := range b.lines {
+				lines = append(lines, cardInline(line))
+			}
+		case blockList:
+			for i, item := range b.lines {
+				lines = append(lines, listBullet(b, i)+cardInline(item))
+			}
+		case blockQuote:
+			for _, line := range b.lines {
+				lines = append(lines, "┃ *"+cardInline(line)+"*")
+			}
+		case blockCode:
+			lines = append(lines, "```"+b.language+"\n"+b.code+"\n```")
+		case blockTable:
+			for i, row := range b.rows {
+				cells := make([]string, len(row))
+				for j, cell := range row {
+					cells[j] = cardInline(cell)
+					if i == 0 {
+						cells[j] = "**" + cells[j] + "**"
+					}
+				}
+				lines = append(lines, strings.Join(cells, " | "))
+			}
+		case blockRule:
+			lines = append(lines, "---")
+		}
+		lines = append(lines, "")
+	}
+
+	return &service.CardMarkdown{
+		Tag:     "markdown",
+		Content: strings.TrimSpace(strings.Join(lines, "\n")),
+	}
+}
+
+// ToCard 将 Markdown 转换为消息卡片，开头的一级标题作为卡片标题
+func ToCard(md, template string) *service.Card {
+	builder := service.NewCardBuilder()
+	blocks := parseBlocks(md)
+	if len(blocks) > 0 && blocks[0].kind == blockHeading && blocks[0].level == 1 {
+		builder.Header(plainInline(blocks[0].lines[0]), template)
+		md = stripFirstHeading(md)
+	}
+	return builder.Element(ToCardMarkdown(md)).Build()
+}
+
+// cardInline 规范化行内 Markdown 为卡片支持的语法
+func cardInline(text string) string {
+	var sb strings.Builder
+	for _, seg := range parseInline(text) {
+		s := seg.text
+		if seg.code {
+			s = "`" + s + "`"
+		}
+		if seg.strike {
+			s = "~~" + s + "~~"
+		}
+		if seg.italic {
+			s = "*" + s + "*"
+		}
+		if seg.bold {
+			s = "**" + s + "**"
+		}
+		if seg.href != "" {
+			s = "[" + s + "](" + seg.href + ")"
+		}
+		sb.WriteString(s)
+	}
+	return sb.String()
+}
+
+// ToPlainText 将 Markdown 转换为纯文本，作为无法使用富文本时的兜底
+func ToPlainText(md string) string {
+	var lines []string
+	for _, b := range parseBlocks(md) {
+		switch b.kind {
+		case blockHeading:
+			lines = append(lines, plainInline(b.lines[0]))
+		case blockParagraph:
+			for _, line := range b.lines {
+				lines = append(lines, plainInline(line))
+			}
+		case blockList:
+			for i, item := range b.lines {
+				lines = append(lines, listBullet(b, i)+plainInline(item))
+			}
+		case blockQuote:
+			for _, line := range b.lines {
+				lines = append(lines, "> "+plainInline(line))
+			}
+		case blockCode:
+			lines = append(lines, b.code)
+		case blockTable:
+			for _, row := range b.rows {
+				cells := make([]string, len(row))
+				for j, cell := range row {
+					cells[j] = plainInline(cell)
+				}
+				lines = append(lines, strings.Join(cells, " | "))
+			}
+		case blockRule:
+			lines = append(lines, "----------")
+		}
+		lines = append(lines, "")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// listBullet 返回列表项前缀
+func listBullet(b *block, index int) string {
+	if b.ordered {
+		return fmt.Sprintf("%d. ", b.start+index)
+	}
+	return "• "
+}
+
+// stripFirstHeading 去除第一个非空行（一级标题）
+func stripFirstHeading(md string) string {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			return strings.Join(lines[i+1:], "\n")
+		}
+	}
+	return md
+}
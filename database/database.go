@@ -17,8 +17,8 @@ func InitDB(dbPath string) (*sql.DB, error) {
 		return nil, err
 	}
 
-	// 连接数据库
-	db, err := sql.Open("sqlite", dbPath)
+	// 连接数据库，发件箱后台投递与接口并发写入，需要设置忙等待超时
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +69,299 @@ func createTables(db *sql.DB) error {
 		CREATE INDEX IF NOT EXISTS idx_phone_number ON user_search_logs(phone_number);
 		CREATE INDEX IF NOT EXISTS idx_search_time ON user_search_logs(search_time);
 	`)
+	if err != nil {
+		return err
+	}
+
+	// 消息模板表
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS message_templates (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name VARCHAR(100) UNIQUE NOT NULL,
+			msg_type VARCHAR(20) NOT NULL,  -- 'text'、'post' 或 'interactive'
+			content TEXT NOT NULL,          -- text/template 格式的模板内容
+			description VARCHAR(255),
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_template_name ON message_templates(name);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 本服务发送的消息记录表，编辑、更新、撤回消息时仅允许操作其中的消息
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS sent_messages (
+			message_id VARCHAR(64) PRIMARY KEY,
+			msg_type VARCHAR(20) NOT NULL,
+			receive_id_type VARCHAR(20),
+			receive_id VARCHAR(128),
+			chat_id VARCHAR(64),
+			recalled INTEGER DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_sent_messages_created_at ON sent_messages(created_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 消息发送日志表，记录每一次发送（含失败）的结果
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS message_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			receive_id_type VARCHAR(20),
+			receive_id VARCHAR(128),
+			reply_to_message_id VARCHAR(64),
+			msg_type VARCHAR(20) NOT NULL,
+			content_hash VARCHAR(64) NOT NULL,   -- 消息内容的SHA256
+			message_id VARCHAR(64),
+			status VARCHAR(10) NOT NULL,         -- 'success' 或 'failed'
+			error_code INTEGER DEFAULT 0,
+			error_msg TEXT,
+			latency_ms INTEGER,
+			caller VARCHAR(128),
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_message_log_receive_id ON message_log(receive_id);
+		CREATE INDEX IF NOT EXISTS idx_message_log_message_id ON message_log(message_id);
+		CREATE INDEX IF NOT EXISTS idx_message_log_created_at ON message_log(created_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 发件箱表，异步发送的消息由后台按 next_attempt_at 投递
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS message_outbox (
+			id VARCHAR(64) PRIMARY KEY,
+			receive_id_type VARCHAR(20),
+			receive_id VARCHAR(128),
+			reply_to_message_id VARCHAR(64),
+			reply_in_thread INTEGER DEFAULT 0,
+			msg_type VARCHAR(20) NOT NULL,
+			content TEXT NOT NULL,
+			caller VARCHAR(128),
+			uuid VARCHAR(64),
+			status VARCHAR(20) DEFAULT 'pending',  -- 'pending' 或 'processing'
+			attempts INTEGER DEFAULT 0,
+			last_error TEXT,
+			next_attempt_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_outbox_status_next ON message_outbox(status, next_attempt_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 死信表，超过最大重试次数或不可重试的消息
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS message_dead_letters (
+			id VARCHAR(64) PRIMARY KEY,
+			receive_id_type VARCHAR(20),
+			receive_id VARCHAR(128),
+			reply_to_message_id VARCHAR(64),
+			reply_in_thread INTEGER DEFAULT 0,
+			msg_type VARCHAR(20) NOT NULL,
+			content TEXT NOT NULL,
+			caller VARCHAR(128),
+			uuid VARCHAR(64),
+			attempts INTEGER DEFAULT 0,
+			error_code INTEGER DEFAULT 0,
+			last_error TEXT,
+			created_at DATETIME,
+			failed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_dead_letters_failed_at ON message_dead_letters(failed_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 幂等键字段在发件箱表创建之后加入，已有的数据库需要补充该字段
+	for _, table := range []string{"message_outbox", "message_dead_letters"} {
+		if err := addColumnIfMissing(db, table, "uuid", "VARCHAR(64)"); err != nil {
+			return err
+		}
+	}
+
+	// 幂等键缓存表，相同幂等键的重复请求直接返回首次发送的结果
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			idempotency_key VARCHAR(64) PRIMARY KEY,
+			message_id VARCHAR(64),
+			response TEXT NOT NULL,  -- 首次发送时飞书返回的消息数据
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_idempotency_created_at ON idempotency_keys(created_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 通讯录部门缓存表，由通讯录同步任务定期全量刷新
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS contact_departments (
+			open_department_id VARCHAR(64) PRIMARY KEY,
+			department_id VARCHAR(64),
+			parent_department_id VARCHAR(64),
+			name VARCHAR(255),
+			leader_user_id VARCHAR(64),
+			chat_id VARCHAR(64),
+			member_count INTEGER DEFAULT 0,
+			synced_at DATETIME NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_contact_departments_parent ON contact_departments(parent_department_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 通讯录用户缓存表
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS contact_users (
+			open_id VARCHAR(64) PRIMARY KEY,
+			user_id VARCHAR(64),
+			union_id VARCHAR(64),
+			name VARCHAR(255),
+			en_name VARCHAR(255),
+			mobile VARCHAR(32),
+			email VARCHAR(255),
+			department_ids TEXT,  -- JSON 数组，open_department_id
+			is_frozen INTEGER DEFAULT 0,
+			is_resigned INTEGER DEFAULT 0,
+			is_activated INTEGER DEFAULT 0,
+			is_exited INTEGER DEFAULT 0,
+			is_unjoin INTEGER DEFAULT 0,
+			synced_at DATETIME NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_contact_users_user_id ON contact_users(user_id);
+		CREATE INDEX IF NOT EXISTS idx_contact_users_mobile ON contact_users(mobile);
+		CREATE INDEX IF NOT EXISTS idx_contact_users_email ON contact_users(email COLLATE NOCASE);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 通讯录用户搜索索引，按姓名、拼音、拼音首字母、英文名、邮箱前缀搜索
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS contact_user_index (
+			open_id VARCHAR(64) PRIMARY KEY,
+			name VARCHAR(255),
+			pinyin VARCHAR(255),    -- 姓名拼音全拼
+			initials VARCHAR(64),   -- 姓名拼音首字母
+			en_name VARCHAR(255),   -- 英文名去除空格后的小写形式
+			email VARCHAR(255)
+		);
+		CREATE INDEX IF NOT EXISTS idx_contact_user_index_pinyin ON contact_user_index(pinyin);
+		CREATE INDEX IF NOT EXISTS idx_contact_user_index_initials ON contact_user_index(initials);
+		CREATE INDEX IF NOT EXISTS idx_contact_user_index_email ON contact_user_index(email);
+	`)
+	if err != nil {
+		return err
+	}
+	// 英文名字段在索引表创建之后加入，已有的数据库需要补充该字段
+	if err := addColumnIfMissing(db, "contact_user_index", "en_name", "VARCHAR(255)"); err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_contact_user_index_en_name ON contact_user_index(en_name)`)
+	if err != nil {
+		return err
+	}
+
+	// 事故作战群表，保证同一事故只创建一个作战群
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS incident_war_rooms (
+			incident_id VARCHAR(128) PRIMARY KEY,
+			status VARCHAR(20) NOT NULL,  -- 'creating' 或 'ready'
+			chat_id VARCHAR(64),
+			card_message_id VARCHAR(64),
+			runbook_message_id VARCHAR(64),
+			share_link TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 告警卡片表，记录每个告警分组最近发送的卡片，告警恢复时更新原卡片
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS alert_cards (
+			group_key TEXT NOT NULL,
+			receive_id_type VARCHAR(20) NOT NULL,
+			receive_id VARCHAR(128) NOT NULL,
+			message_id VARCHAR(64) NOT NULL,
+			status VARCHAR(20) NOT NULL,  -- 'firing' 或 'resolved'
+			fingerprints TEXT,            -- 卡片中触发过的告警指纹，JSON 数组
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (group_key, receive_id_type, receive_id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 第三方 webhook 集成的路由规则，按来源与仓库（或告警接收器）匹配接收消息的群或用户
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS integration_routes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			source VARCHAR(20) NOT NULL,         -- 'github'、'gitlab'、'grafana' 或 '*'
+			pattern VARCHAR(255) NOT NULL,       -- 仓库全名，支持通配符，如 'iexe/*'
+			receive_id_type VARCHAR(20) NOT NULL,
+			receive_id VARCHAR(128) NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (source, pattern, receive_id_type, receive_id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 自定义机器人兼容地址，每个 token 对应一个接收消息的群或用户
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS bot_hooks (
+			token VARCHAR(64) PRIMARY KEY,
+			name VARCHAR(100),
+			receive_id_type VARCHAR(20) NOT NULL,
+			receive_id VARCHAR(128) NOT NULL,
+			secret VARCHAR(128),  -- 签名校验密钥，为空时不校验签名
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
 
 	log.Println("Database tables created successfully")
 	return err
-}
\ No newline at end of file
+}
+
+// addColumnIfMissing 表中不存在该字段时添加字段，用于升级已有的数据库
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + definition)
+	return err
+}
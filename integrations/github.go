@@ -0,0 +1,315 @@
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"oapi-sdk-go-demo/service"
+	"strings"
+)
+
+// 推送卡片中最多展示的提交数
+const maxCommitsPerCard = 5
+
+// GitHubAdapter 处理 GitHub 仓库 webhook，支持 push、pull_request、release 和 workflow_run 事件
+type GitHubAdapter struct {
+	secret string
+}
+
+// NewGitHubAdapter 创建 GitHub 适配器，secret 与 webhook 配置中的 Secret 一致
+func NewGitHubAdapter(secret string) *GitHubAdapter {
+	return &GitHubAdapter{secret: secret}
+}
+
+// Source 来源名称
+func (a *GitHubAdapter) Source() string {
+	return "github"
+}
+
+// Verify 校验 X-Hub-Signature-256 请求头中的 HMAC-SHA256 签名
+func (a *GitHubAdapter) Verify(header http.Header, body []byte) error {
+	signature, ok := strings.CutPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+	if !ok || !verifyHMAC(a.secret, body, signature) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+type githubUser struct {
+	Login   string `json:"login"`
+	HTMLURL string `json:"html_url"`
+}
+
+type githubRepository struct {
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type githubPushEvent struct {
+	Ref        string           `json:"ref"`
+	Created    bool             `json:"created"`
+	Deleted    bool             `json:"deleted"`
+	Forced     bool             `json:"forced"`
+	Compare    string           `json:"compare"`
+	Repository githubRepository `json:"repository"`
+	Pusher     struct {
+		Name string `json:"name"`
+	} `json:"pusher"`
+	Sender  githubUser `json:"sender"`
+	Commits []struct {
+		Id      string `json:"id"`
+		Message string `json:"message"`
+		URL     string `json:"url"`
+		Author  struct {
+			Name string `json:"name"`
+		} `json:"author"`
+	} `json:"commits"`
+}
+
+type githubPullRequestEvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Title   string     `json:"title"`
+		Body    string     `json:"body"`
+		HTMLURL string     `json:"html_url"`
+		Merged  bool       `json:"merged"`
+		Draft   bool       `json:"draft"`
+		User    githubUser `json:"user"`
+		Head    struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository githubRepository `json:"repository"`
+	Sender     githubUser       `json:"sender"`
+}
+
+type githubReleaseEvent struct {
+	Action  string `json:"action"`
+	Release struct {
+		TagName    string     `json:"tag_name"`
+		Name       string     `json:"name"`
+		Body       string     `json:"body"`
+		HTMLURL    string     `json:"html_url"`
+		Prerelease bool       `json:"prerelease"`
+		Author     githubUser `json:"author"`
+	} `json:"release"`
+	Repository githubRepository `json:"repository"`
+}
+
+type githubWorkflowRunEvent struct {
+	Action      string `json:"action"`
+	WorkflowRun struct {
+		Name         string     `json:"name"`
+		DisplayTitle string     `json:"display_title"`
+		RunNumber    int        `json:"run_number"`
+		Event        string     `json:"event"`
+		HeadBranch   string     `json:"head_branch"`
+		HeadSha      string     `json:"head_sha"`
+		Conclusion   string     `json:"conclusion"`
+		HTMLURL      string     `json:"html_url"`
+		Actor        githubUser `json:"actor"`
+	} `json:"workflow_run"`
+	Repository githubRepository `json:"repository"`
+}
+
+// Convert 按 X-GitHub-Event 请求头转换推送
+func (a *GitHubAdapter) Convert(header http.Header, body []byte) (*Message, error) {
+	event := header.Get("X-GitHub-Event")
+
+	var card *service.Card
+	var repository string
+	var err error
+	switch event {
+	case "push":
+		var payload githubPushEvent
+		if err = json.Unmarshal(body, &payload); err == nil {
+			card, repository = githubPushCard(&payload), payload.Repository.FullName
+		}
+	case "pull_request":
+		var payload githubPullRequestEvent
+		if err = json.Unmarshal(body, &payload); err == nil {
+			card, repository = githubPullRequestCard(&payload), payload.Repository.FullName
+		}
+	case "release":
+		var payload githubReleaseEvent
+		if err = json.Unmarshal(body, &payload); err == nil {
+			card, repository = githubReleaseCard(&payload), payload.Repository.FullName
+		}
+	case "workflow_run":
+		var payload githubWorkflowRunEvent
+		if err = json.Unmarshal(body, &payload); err == nil {
+			card, repository = githubWorkflowRunCard(&payload), payload.Repository.FullName
+		}
+	default:
+		// ping 等其他事件不通知
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse github %s event failed: %v", event, err)
+	}
+	if card == nil {
+		return nil, nil
+	}
+
+	return &Message{
+		Event:      event,
+		RouteKey:   repository,
+		DeliveryId: header.Get("X-GitHub-Delivery"),
+		Card:       card,
+	}, nil
+}
+
+// githubPushCard 推送提交或删除分支、标签的通知
+func githubPushCard(payload *githubPushEvent) *service.Card {
+	ref := githubRefName(payload.Ref)
+	pusher := defaultString(payload.Pusher.Name, payload.Sender.Login)
+	repo := payload.Repository.FullName
+
+	if payload.Deleted {
+		return service.NewCardBuilder().
+			Header(fmt.Sprintf("[%s] %s 删除了 %s", repo, pusher, ref), service.CardTemplateGrey).
+			Build()
+	}
+	if len(payload.Commits) == 0 && !payload.Created {
+		return nil
+	}
+
+	title := fmt.Sprintf("[%s] %s 推送了 %d 个提交到 %s", repo, pusher, len(payload.Commits), ref)
+	if payload.Created && len(payload.Commits) == 0 {
+		title = fmt.Sprintf("[%s] %s 创建了 %s", repo, pusher, ref)
+	}
+	template := service.CardTemplateBlue
+	if payload.Forced {
+		title += "（强制推送）"
+		template = service.CardTemplateOrange
+	}
+
+	builder := service.NewCardBuilder().Header(title, template)
+	if len(payload.Commits) > 0 {
+		var lines []string
+		for i, commit := range payload.Commits {
+			if i == maxCommitsPerCard {
+				lines = append(lines, fmt.Sprintf("另有 %d 个提交", len(payload.Commits)-maxCommitsPerCard))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("[%s](%s) %s - %s",
+				shortSha(commit.Id), commit.URL, truncate(firstLine(commit.Message), 80), commit.Author.Name))
+		}
+		builder.Markdown(strings.Join(lines, "\n"))
+	}
+	if payload.Compare != "" {
+		builder.Buttons(service.NewCardURLButton("查看变更", payload.Compare, service.CardButtonDefault))
+	}
+	return builder.Build()
+}
+
+// githubPullRequestCard PR 创建、重新打开、可评审、合并与关闭的通知，其他操作不通知
+func githubPullRequestCard(payload *githubPullRequestEvent) *service.Card {
+	pr := payload.PullRequest
+	var verb, template string
+	switch {
+	case payload.Action == "opened" && pr.Draft:
+		return nil
+	case payload.Action == "opened":
+		verb, template = "创建了", service.CardTemplateBlue
+	case payload.Action == "reopened":
+		verb, template = "重新打开了", service.CardTemplateBlue
+	case payload.Action == "ready_for_review":
+		verb, template = "提交评审", service.CardTemplateBlue
+	case payload.Action == "closed" && pr.Merged:
+		verb, template = "合并了", service.CardTemplatePurple
+	case payload.Action == "closed":
+		verb, template = "关闭了", service.CardTemplateGrey
+	default:
+		return nil
+	}
+
+	builder := service.NewCardBuilder().
+		Header(fmt.Sprintf("[%s] %s %s PR #%d", payload.Repository.FullName, payload.Sender.Login, verb, payload.Number), template).
+		Markdown(fmt.Sprintf("**%s**", pr.Title)).
+		Fields(
+			service.NewCardField(fmt.Sprintf("**作者**\n%s", pr.User.Login), true),
+			service.NewCardField(fmt.Sprintf("**分支**\n%s → %s", pr.Head.Ref, pr.Base.Ref), true),
+		)
+	if pr.Body != "" && (payload.Action == "opened" || payload.Action == "ready_for_review") {
+		builder.Markdown(truncate(pr.Body, 300))
+	}
+	return builder.
+		Buttons(service.NewCardURLButton("查看 PR", pr.HTMLURL, service.CardButtonPrimary)).
+		Build()
+}
+
+// githubReleaseCard 版本发布的通知
+func githubReleaseCard(payload *githubReleaseEvent) *service.Card {
+	if payload.Action != "published" {
+		return nil
+	}
+
+	release := payload.Release
+	title := fmt.Sprintf("[%s] 发布了 %s", payload.Repository.FullName, defaultString(release.Name, release.TagName))
+	if release.Prerelease {
+		title += "（预发布）"
+	}
+	builder := service.NewCardBuilder().
+		Header(title, service.CardTemplateGreen).
+		Fields(
+			service.NewCardField(fmt.Sprintf("**标签**\n%s", release.TagName), true),
+			service.NewCardField(fmt.Sprintf("**发布者**\n%s", release.Author.Login), true),
+		)
+	if release.Body != "" {
+		builder.Markdown(truncate(release.Body, 500))
+	}
+	return builder.
+		Buttons(service.NewCardURLButton("查看版本", release.HTMLURL, service.CardButtonPrimary)).
+		Build()
+}
+
+// githubWorkflowRunCard 工作流运行结束的通知
+func githubWorkflowRunCard(payload *githubWorkflowRunEvent) *service.Card {
+	if payload.Action != "completed" {
+		return nil
+	}
+
+	run := payload.WorkflowRun
+	var result, template string
+	switch run.Conclusion {
+	case "success":
+		result, template = "成功", service.CardTemplateGreen
+	case "failure", "timed_out", "startup_failure":
+		result, template = "失败", service.CardTemplateRed
+	case "cancelled":
+		result, template = "已取消", service.CardTemplateGrey
+	default:
+		result, template = run.Conclusion, service.CardTemplateOrange
+	}
+
+	builder := service.NewCardBuilder().
+		Header(fmt.Sprintf("[%s] %s #%d %s", payload.Repository.FullName, run.Name, run.RunNumber, result), template)
+	if run.DisplayTitle != "" {
+		builder.Markdown(fmt.Sprintf("**%s**", run.DisplayTitle))
+	}
+	return builder.
+		Fields(
+			service.NewCardField(fmt.Sprintf("**分支**\n%s", run.HeadBranch), true),
+			service.NewCardField(fmt.Sprintf("**提交**\n%s", shortSha(run.HeadSha)), true),
+			service.NewCardField(fmt.Sprintf("**触发者**\n%s", run.Actor.Login), true),
+			service.NewCardField(fmt.Sprintf("**触发事件**\n%s", run.Event), true),
+		).
+		Buttons(service.NewCardURLButton("查看运行", run.HTMLURL, service.CardButtonPrimary)).
+		Build()
+}
+
+// githubRefName 去除 refs/heads/ 或 refs/tags/ 前缀
+func githubRefName(ref string) string {
+	if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+		return name
+	}
+	if name, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+		return "标签 " + name
+	}
+	return ref
+}
@@ -0,0 +1,195 @@
+package integrations
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"oapi-sdk-go-demo/service"
+	"strings"
+)
+
+// GitLabAdapter 处理 GitLab 项目 webhook，支持流水线和合并请求事件
+type GitLabAdapter struct {
+	token string
+}
+
+// NewGitLabAdapter 创建 GitLab 适配器，token 与 webhook 配置中的 Secret token 一致
+func NewGitLabAdapter(token string) *GitLabAdapter {
+	return &GitLabAdapter{token: token}
+}
+
+// Source 来源名称
+func (a *GitLabAdapter) Source() string {
+	return "gitlab"
+}
+
+// Verify 校验 X-Gitlab-Token 请求头，GitLab 不对推送签名，只携带配置的密钥
+func (a *GitLabAdapter) Verify(header http.Header, body []byte) error {
+	if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), []byte(a.token)) != 1 {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+type gitlabUser struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+type gitlabProject struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+type gitlabPipelineEvent struct {
+	ObjectAttributes struct {
+		Id       int64  `json:"id"`
+		Ref      string `json:"ref"`
+		Tag      bool   `json:"tag"`
+		Sha      string `json:"sha"`
+		Status   string `json:"status"`
+		Source   string `json:"source"`
+		Duration int64  `json:"duration"`
+		URL      string `json:"url"`
+	} `json:"object_attributes"`
+	User    gitlabUser    `json:"user"`
+	Project gitlabProject `json:"project"`
+	Commit  struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	} `json:"commit"`
+}
+
+type gitlabMergeRequestEvent struct {
+	ObjectAttributes struct {
+		Iid          int64  `json:"iid"`
+		Title        string `json:"title"`
+		Description  string `json:"description"`
+		URL          string `json:"url"`
+		Action       string `json:"action"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+		Draft        bool   `json:"draft"`
+	} `json:"object_attributes"`
+	User    gitlabUser    `json:"user"`
+	Project gitlabProject `json:"project"`
+}
+
+// Convert 按推送内容中的 object_kind 转换推送
+func (a *GitLabAdapter) Convert(header http.Header, body []byte) (*Message, error) {
+	var kind struct {
+		ObjectKind string `json:"object_kind"`
+	}
+	if err := json.Unmarshal(body, &kind); err != nil {
+		return nil, fmt.Errorf("parse gitlab event failed: %v", err)
+	}
+
+	var card *service.Card
+	var project string
+	var err error
+	switch kind.ObjectKind {
+	case "pipeline":
+		var payload gitlabPipelineEvent
+		if err = json.Unmarshal(body, &payload); err == nil {
+			card, project = gitlabPipelineCard(&payload), payload.Project.PathWithNamespace
+		}
+	case "merge_request":
+		var payload gitlabMergeRequestEvent
+		if err = json.Unmarshal(body, &payload); err == nil {
+			card, project = gitlabMergeRequestCard(&payload), payload.Project.PathWithNamespace
+		}
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse gitlab %s event failed: %v", kind.ObjectKind, err)
+	}
+	if card == nil {
+		return nil, nil
+	}
+
+	return &Message{
+		Event:      kind.ObjectKind,
+		RouteKey:   project,
+		DeliveryId: header.Get("X-Gitlab-Event-UUID"),
+		Card:       card,
+	}, nil
+}
+
+// gitlabPipelineCard 流水线结束的通知，运行中等中间状态不通知
+func gitlabPipelineCard(payload *gitlabPipelineEvent) *service.Card {
+	pipeline := payload.ObjectAttributes
+	var result, template string
+	switch pipeline.Status {
+	case "success":
+		result, template = "成功", service.CardTemplateGreen
+	case "failed":
+		result, template = "失败", service.CardTemplateRed
+	case "canceled":
+		result, template = "已取消", service.CardTemplateGrey
+	default:
+		return nil
+	}
+
+	url := pipeline.URL
+	if url == "" {
+		url = fmt.Sprintf("%s/-/pipelines/%d", strings.TrimRight(payload.Project.WebURL, "/"), pipeline.Id)
+	}
+	ref := pipeline.Ref
+	if pipeline.Tag {
+		ref = "标签 " + ref
+	}
+
+	builder := service.NewCardBuilder().
+		Header(fmt.Sprintf("[%s] 流水线 #%d %s", payload.Project.PathWithNamespace, pipeline.Id, result), template)
+	if payload.Commit.Title != "" {
+		builder.Markdown(fmt.Sprintf("[%s](%s) %s", shortSha(pipeline.Sha), payload.Commit.URL, truncate(payload.Commit.Title, 80)))
+	}
+	fields := []*service.CardField{
+		service.NewCardField(fmt.Sprintf("**分支**\n%s", ref), true),
+		service.NewCardField(fmt.Sprintf("**触发者**\n%s", defaultString(payload.User.Name, payload.User.Username)), true),
+	}
+	if pipeline.Duration > 0 {
+		fields = append(fields, service.NewCardField(fmt.Sprintf("**耗时**\n%d分%d秒", pipeline.Duration/60, pipeline.Duration%60), true))
+	}
+	return builder.
+		Fields(fields...).
+		Buttons(service.NewCardURLButton("查看流水线", url, service.CardButtonPrimary)).
+		Build()
+}
+
+// gitlabMergeRequestCard 合并请求创建、重新打开、批准、合并与关闭的通知，更新等其他操作不通知
+func gitlabMergeRequestCard(payload *gitlabMergeRequestEvent) *service.Card {
+	mr := payload.ObjectAttributes
+	var verb, template string
+	switch mr.Action {
+	case "open":
+		if mr.Draft {
+			return nil
+		}
+		verb, template = "创建了", service.CardTemplateBlue
+	case "reopen":
+		verb, template = "重新打开了", service.CardTemplateBlue
+	case "approved":
+		verb, template = "批准了", service.CardTemplateGreen
+	case "merge":
+		verb, template = "合并了", service.CardTemplatePurple
+	case "close":
+		verb, template = "关闭了", service.CardTemplateGrey
+	default:
+		return nil
+	}
+
+	builder := service.NewCardBuilder().
+		Header(fmt.Sprintf("[%s] %s %s MR !%d", payload.Project.PathWithNamespace,
+			defaultString(payload.User.Name, payload.User.Username), verb, mr.Iid), template).
+		Markdown(fmt.Sprintf("**%s**", mr.Title)).
+		Fields(service.NewCardField(fmt.Sprintf("**分支**\n%s → %s", mr.SourceBranch, mr.TargetBranch), true))
+	if mr.Description != "" && mr.Action == "open" {
+		builder.Markdown(truncate(mr.Description, 300))
+	}
+	return builder.
+		Buttons(service.NewCardURLButton("查看合并请求", mr.URL, service.CardButtonPrimary)).
+		Build()
+}
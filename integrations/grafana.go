@@ -0,0 +1,218 @@
+package integrations
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"oapi-sdk-go-demo/service"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// 告警卡片中最多展示的告警数
+	maxGrafanaAlertsPerCard = 10
+	// 签名时间戳与当前时间的最大偏差，超出时视为重放的推送
+	grafanaSignatureTolerance = 5 * time.Minute
+)
+
+// GrafanaAdapter 处理 Grafana 告警 webhook 联络点的推送
+type GrafanaAdapter struct {
+	secret string
+}
+
+// NewGrafanaAdapter 创建 Grafana 适配器
+// 联络点配置 HMAC 签名时 secret 为签名密钥，否则为 Authorization 请求头中的 Bearer 凭证
+func NewGrafanaAdapter(secret string) *GrafanaAdapter {
+	return &GrafanaAdapter{secret: secret}
+}
+
+// Source 来源名称
+func (a *GrafanaAdapter) Source() string {
+	return "grafana"
+}
+
+// Verify 校验 X-Grafana-Alerting-Signature 签名，未签名时校验 Bearer 凭证
+// 联络点配置了时间戳请求头时，签名内容为 "时间戳:请求体"，且时间戳超过 5 分钟的推送会被拒绝
+func (a *GrafanaAdapter) Verify(header http.Header, body []byte) error {
+	if signature := header.Get("X-Grafana-Alerting-Signature"); signature != "" {
+		timestamp := header.Get("X-Grafana-Alerting-Signature-Timestamp")
+		signed := body
+		if timestamp != "" {
+			signed = append([]byte(timestamp+":"), body...)
+		}
+		if !verifyHMAC(a.secret, signed, signature) {
+			return ErrSignatureMismatch
+		}
+		if timestamp != "" {
+			seconds, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil || time.Since(time.Unix(seconds, 0)).Abs() > grafanaSignatureTolerance {
+				return ErrSignatureMismatch
+			}
+		}
+		return nil
+	}
+
+	token, ok := strings.CutPrefix(header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.secret)) != 1 {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+type grafanaPayload struct {
+	Receiver          string            `json:"receiver"`
+	GroupKey          string            `json:"groupKey"`
+	Status            string            `json:"status"`
+	Title             string            `json:"title"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Alerts            []*grafanaAlert   `json:"alerts"`
+}
+
+type grafanaAlert struct {
+	Status       string            `json:"status"`
+	Fingerprint  string            `json:"fingerprint"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	ValueString  string            `json:"valueString"`
+	GeneratorURL string            `json:"generatorURL"`
+	SilenceURL   string            `json:"silenceURL"`
+	DashboardURL string            `json:"dashboardURL"`
+	PanelURL     string            `json:"panelURL"`
+}
+
+// Convert 转换告警推送，路由键为联络点名称
+func (a *GrafanaAdapter) Convert(header http.Header, body []byte) (*Message, error) {
+	var payload grafanaPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("parse grafana alert failed: %v", err)
+	}
+	if len(payload.Alerts) == 0 {
+		return nil, nil
+	}
+
+	return &Message{
+		Event:      "alert",
+		RouteKey:   payload.Receiver,
+		DeliveryId: grafanaDeliveryId(&payload),
+		Card:       grafanaAlertCard(&payload),
+	}, nil
+}
+
+// grafanaDeliveryId Grafana 不提供推送ID，由告警分组、状态和分组内的告警确定，
+// 重放的推送得到相同的ID，分组内告警变化时仍会发送
+func grafanaDeliveryId(payload *grafanaPayload) string {
+	if payload.GroupKey == "" {
+		return ""
+	}
+	alerts := make([]string, 0, len(payload.Alerts))
+	for _, alert := range payload.Alerts {
+		alerts = append(alerts, fmt.Sprintf("%s:%s:%d", alert.Fingerprint, alert.Status, alert.StartsAt.Unix()))
+	}
+	sort.Strings(alerts)
+	sum := sha256.Sum256([]byte(payload.GroupKey + "\n" + payload.Status + "\n" + strings.Join(alerts, ",")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// grafanaAlertCard 告警分组卡片，触发中的告警按最高级别着色，全部恢复时为绿色
+func grafanaAlertCard(payload *grafanaPayload) *service.Card {
+	level := 0
+	for _, alert := range payload.Alerts {
+		if alert.Status == "firing" {
+			level = max(level, grafanaSeverityLevel(alert.Labels["severity"]))
+		}
+	}
+	template := service.CardTemplateGreen
+	switch level {
+	case 1:
+		template = service.CardTemplateBlue
+	case 2:
+		template = service.CardTemplateOrange
+	case 3:
+		template = service.CardTemplateRed
+	}
+
+	title := payload.Title
+	if title == "" {
+		title = fmt.Sprintf("[%s] %s", strings.ToUpper(payload.Status), defaultString(payload.GroupLabels["alertname"], payload.Receiver))
+	}
+	builder := service.NewCardBuilder().Header(title, template)
+	if summary := payload.CommonAnnotations["summary"]; summary != "" {
+		builder.Markdown(summary)
+	}
+
+	for i, alert := range payload.Alerts {
+		if i == maxGrafanaAlertsPerCard {
+			builder.Markdown(fmt.Sprintf("另有 %d 条告警未展示", len(payload.Alerts)-maxGrafanaAlertsPerCard))
+			break
+		}
+		builder.Divider().Markdown(grafanaAlertMarkdown(alert))
+	}
+	if payload.TruncatedAlerts > 0 {
+		builder.Markdown(fmt.Sprintf("Grafana 截断了 %d 条告警", payload.TruncatedAlerts))
+	}
+
+	if payload.ExternalURL != "" {
+		builder.Buttons(service.NewCardURLButton("打开 Grafana", payload.ExternalURL, service.CardButtonDefault))
+	}
+	return builder.Note("联络点 " + defaultString(payload.Receiver, "-")).Build()
+}
+
+// grafanaAlertMarkdown 单条告警的状态、摘要、当前值与相关链接
+func grafanaAlertMarkdown(alert *grafanaAlert) string {
+	status := "**[触发中]**"
+	if alert.Status == "resolved" {
+		status = "**[已恢复]**"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s **%s**", status, defaultString(alert.Labels["alertname"], "-"))
+	if text := defaultString(alert.Annotations["summary"], alert.Annotations["description"]); text != "" {
+		b.WriteString(" " + text)
+	}
+	if alert.ValueString != "" {
+		b.WriteString("\n当前值：" + truncate(alert.ValueString, 200))
+	}
+	b.WriteString("\n开始于 " + alert.StartsAt.Local().Format("2006-01-02 15:04:05"))
+	if alert.Status == "resolved" && !alert.EndsAt.IsZero() {
+		b.WriteString("，恢复于 " + alert.EndsAt.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	var links []string
+	for _, link := range []struct{ text, url string }{
+		{"规则", alert.GeneratorURL},
+		{"面板", alert.PanelURL},
+		{"仪表盘", alert.DashboardURL},
+		{"静默", alert.SilenceURL},
+	} {
+		if link.url != "" && (link.text != "静默" || alert.Status == "firing") {
+			links = append(links, fmt.Sprintf("[%s](%s)", link.text, link.url))
+		}
+	}
+	if len(links) > 0 {
+		b.WriteString("\n" + strings.Join(links, " · "))
+	}
+	return b.String()
+}
+
+// grafanaSeverityLevel 按 severity 标签划分告警级别，未设置时视为 warning
+func grafanaSeverityLevel(severity string) int {
+	switch strings.ToLower(severity) {
+	case "critical", "fatal", "emergency", "page", "error", "p0", "p1":
+		return 3
+	case "info", "notice", "p3", "p4":
+		return 1
+	default:
+		return 2
+	}
+}
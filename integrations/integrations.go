@@ -0,0 +1,125 @@
+/*
+ 第三方 webhook 集成，将 Grafana、GitHub、GitLab 等工具的推送转换为飞书消息卡片：
+ 1. 每个来源实现 Adapter，负责校验签名并转换推送内容
+ 2. 转换结果携带路由键（仓库全名或告警接收器），按路由规则发送到对应的群或用户
+*/
+
+package integrations
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"oapi-sdk-go-demo/config"
+	"oapi-sdk-go-demo/service"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrSignatureMismatch 推送的签名或密钥校验失败
+var ErrSignatureMismatch = errors.New("webhook signature mismatch")
+
+// Message 推送转换后的飞书消息
+type Message struct {
+	Event      string // 推送的事件类型，如 push、pipeline
+	RouteKey   string // 用于匹配路由规则，如 GitHub 仓库全名 iexe/feishu_api
+	DeliveryId string // 推送ID，重复推送时用于去重，来源未提供时为空
+	Card       *service.Card
+}
+
+// Adapter 第三方 webhook 适配器
+type Adapter interface {
+	// Source 来源名称，同时作为推送地址中的路径，如 github
+	Source() string
+	// Verify 校验推送的签名或密钥
+	Verify(header http.Header, body []byte) error
+	// Convert 将推送转换为消息，不需要通知的事件（如 ping、未支持的事件类型）返回 nil
+	Convert(header http.Header, body []byte) (*Message, error)
+}
+
+// Registry 已启用的适配器
+type Registry struct {
+	mu       sync.RWMutex
+	adapters map[string]Adapter
+}
+
+// NewRegistry 创建空的适配器注册表
+func NewRegistry() *Registry {
+	return &Registry{adapters: make(map[string]Adapter)}
+}
+
+// NewDefaultRegistry 创建注册表并启用已配置密钥的内置适配器
+func NewDefaultRegistry(cfg *config.Config) *Registry {
+	r := NewRegistry()
+	if cfg.GitHubWebhookSecret != "" {
+		r.Register(NewGitHubAdapter(cfg.GitHubWebhookSecret))
+	}
+	if cfg.GitLabWebhookToken != "" {
+		r.Register(NewGitLabAdapter(cfg.GitLabWebhookToken))
+	}
+	if cfg.GrafanaWebhookSecret != "" {
+		r.Register(NewGrafanaAdapter(cfg.GrafanaWebhookSecret))
+	}
+	return r
+}
+
+// Register 注册适配器，同名来源的适配器会被替换
+func (r *Registry) Register(adapter Adapter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.adapters[adapter.Source()] = adapter
+}
+
+// Get 获取来源对应的适配器
+func (r *Registry) Get(source string) (Adapter, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	adapter, ok := r.adapters[source]
+	return adapter, ok
+}
+
+// verifyHMAC 校验十六进制编码的 HMAC-SHA256 签名
+func verifyHMAC(secret string, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// firstLine 返回文本的第一行，用于提交信息等多行文本
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// truncate 按字符截断过长的文本
+func truncate(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
+
+func defaultString(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// shortSha 提交哈希的前 7 位
+func shortSha(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,119 @@
+package integrations
+
+import (
+	"database/sql"
+	"fmt"
+	"path"
+	"time"
+)
+
+// 匹配所有来源的路由规则
+const AnySource = "*"
+
+// Route 路由规则，将来源与仓库（或告警接收器）匹配的推送发送到指定的群或用户
+type Route struct {
+	Id            int64     `json:"id"`
+	Source        string    `json:"source" binding:"required"`  // 来源名称，* 匹配所有来源
+	Pattern       string    `json:"pattern" binding:"required"` // 支持通配符，如 iexe/*，* 匹配所有仓库
+	ReceiveIdType string    `json:"receive_id_type" binding:"required"`
+	ReceiveId     string    `json:"receive_id" binding:"required"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AddRoute 添加路由规则，相同规则重复添加时不会重复保存
+func AddRoute(db *sql.DB, route *Route) error {
+	if _, err := path.Match(route.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", route.Pattern, err)
+	}
+
+	_, err := db.Exec(`
+		INSERT OR IGNORE INTO integration_routes (source, pattern, receive_id_type, receive_id)
+		VALUES (?, ?, ?, ?)
+	`, route.Source, route.Pattern, route.ReceiveIdType, route.ReceiveId)
+	if err != nil {
+		return err
+	}
+
+	return db.QueryRow(`
+		SELECT id, created_at FROM integration_routes
+		WHERE source = ? AND pattern = ? AND receive_id_type = ? AND receive_id = ?
+	`, route.Source, route.Pattern, route.ReceiveIdType, route.ReceiveId).Scan(&route.Id, &route.CreatedAt)
+}
+
+// ListRoutes 获取路由规则，source 不为空时只返回该来源的规则
+func ListRoutes(db *sql.DB, source string) ([]*Route, error) {
+	query := `SELECT id, source, pattern, receive_id_type, receive_id, created_at FROM integration_routes`
+	var args []interface{}
+	if source != "" {
+		query += ` WHERE source = ?`
+		args = append(args, source)
+	}
+	query += ` ORDER BY source, pattern, id`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routes := []*Route{}
+	for rows.Next() {
+		route := &Route{}
+		if err := rows.Scan(&route.Id, &route.Source, &route.Pattern, &route.ReceiveIdType, &route.ReceiveId, &route.CreatedAt); err != nil {
+			return nil, err
+		}
+		routes = append(routes, route)
+	}
+	return routes, rows.Err()
+}
+
+// DeleteRoute 删除路由规则，规则不存在时返回 false
+func DeleteRoute(db *sql.DB, id int64) (bool, error) {
+	result, err := db.Exec(`DELETE FROM integration_routes WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// MatchRoutes 获取与推送匹配的路由规则，同一接收者只返回一次
+func MatchRoutes(db *sql.DB, source, routeKey string) ([]*Route, error) {
+	rows, err := db.Query(`
+		SELECT id, source, pattern, receive_id_type, receive_id, created_at
+		FROM integration_routes WHERE source = ? OR source = ?
+		ORDER BY id
+	`, source, AnySource)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var routes []*Route
+	seen := make(map[string]bool)
+	for rows.Next() {
+		route := &Route{}
+		if err := rows.Scan(&route.Id, &route.Source, &route.Pattern, &route.ReceiveIdType, &route.ReceiveId, &route.CreatedAt); err != nil {
+			return nil, err
+		}
+		if !matchPattern(route.Pattern, routeKey) {
+			continue
+		}
+		target := route.ReceiveIdType + ":" + route.ReceiveId
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+		routes = append(routes, route)
+	}
+	return routes, rows.Err()
+}
+
+// matchPattern 按路径通配符匹配路由键，* 单独使用时匹配所有路由键
+func matchPattern(pattern, routeKey string) bool {
+	if pattern == "*" {
+		return true
+	}
+	matched, _ := path.Match(pattern, routeKey)
+	return matched
+}
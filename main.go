@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 
@@ -8,6 +9,7 @@ import (
 	"oapi-sdk-go-demo/api"
 	"oapi-sdk-go-demo/config"
 	"oapi-sdk-go-demo/database"
+	"oapi-sdk-go-demo/integrations"
 	"oapi-sdk-go-demo/service"
 )
 
@@ -23,7 +25,16 @@ func main() {
 	defer db.Close()
 
 	// 初始化服务
-	feishuService := service.NewFeishuService(cfg)
+	feishuService := service.NewFeishuService(cfg, db)
+
+	// 启动发件箱后台投递
+	feishuService.StartOutbox(context.Background())
+
+	// 启动通讯录定时同步
+	feishuService.StartContactSync(context.Background())
+
+	// 配置为长连接模式时通过 WebSocket 接收事件
+	feishuService.StartEventLongConnection(context.Background())
 
 	// 设置Gin路由
 	router := gin.Default()
@@ -32,7 +43,7 @@ func main() {
 	router.Static("/static", "./static")
 	
 	// 注册API路由
-	api.SetupRoutes(router, feishuService, db)
+	api.SetupRoutes(router, feishuService, db, integrations.NewDefaultRegistry(cfg))
 
 	// 启动服务器
 	log.Printf("Server starting on http://localhost:%s", cfg.Port)
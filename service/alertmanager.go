@@ -0,0 +1,328 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 告警状态
+const (
+	AlertStatusFiring   = "firing"
+	AlertStatusResolved = "resolved"
+)
+
+// 单张卡片中每种状态最多展示的告警数
+const maxAlertsPerCard = 10
+
+// AlertmanagerPayload Alertmanager webhook 推送的告警分组（version 4）
+type AlertmanagerPayload struct {
+	Version           string               `json:"version"`
+	GroupKey          string               `json:"groupKey"`
+	TruncatedAlerts   int                  `json:"truncatedAlerts"`
+	Status            string               `json:"status"` // firing 或 resolved
+	Receiver          string               `json:"receiver"`
+	GroupLabels       map[string]string    `json:"groupLabels"`
+	CommonLabels      map[string]string    `json:"commonLabels"`
+	CommonAnnotations map[string]string    `json:"commonAnnotations"`
+	ExternalURL       string               `json:"externalURL"`
+	Alerts            []*AlertmanagerAlert `json:"alerts"`
+}
+
+// AlertmanagerAlert 单条告警
+type AlertmanagerAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// AlertNotification 告警推送结果
+type AlertNotification struct {
+	MessageId string `json:"message_id"`
+	Status    string `json:"status"`
+	Updated   bool   `json:"updated"` // 更新了已发送的卡片，未发送新消息
+}
+
+// NotifyAlertmanager 将一个告警分组渲染为卡片发送，每个分组对应一张卡片
+// 分组内没有新触发的告警时（重复通知、部分或全部恢复）更新原卡片，否则发送新卡片
+func (s *FeishuService) NotifyAlertmanager(receiveIdType, receiveId string, payload *AlertmanagerPayload) (*AlertNotification, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("alert card store is not configured")
+	}
+	if payload.GroupKey == "" {
+		return nil, fmt.Errorf("groupKey is required")
+	}
+
+	// 同一分组的通知串行处理，避免重复发送卡片
+	s.alertCardsMu.Lock()
+	defer s.alertCardsMu.Unlock()
+
+	var messageId, status string
+	var fingerprintsJSON sql.NullString
+	err := s.db.QueryRow(`
+		SELECT message_id, status, fingerprints FROM alert_cards
+		WHERE group_key = ? AND receive_id_type = ? AND receive_id = ?
+	`, payload.GroupKey, receiveIdType, receiveId).Scan(&messageId, &status, &fingerprintsJSON)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	if fingerprintsJSON.Valid {
+		var fingerprints []string
+		if err := json.Unmarshal([]byte(fingerprintsJSON.String), &fingerprints); err == nil {
+			for _, fp := range fingerprints {
+				known[fp] = true
+			}
+		}
+	}
+
+	card := buildAlertCard(payload)
+	firing := firingFingerprints(payload)
+
+	if messageId != "" && status == AlertStatusFiring && allKnown(firing, known) {
+		err := s.UpdateCardMessage(messageId, card)
+		if err == nil {
+			s.saveAlertCard(payload, receiveIdType, receiveId, messageId, known)
+			return &AlertNotification{MessageId: messageId, Status: payload.Status, Updated: true}, nil
+		}
+		log.Printf("Failed to update alert card %s, sending a new one: %v", messageId, err)
+	}
+
+	data, err := s.SendCardMessage(receiveIdType, receiveId, card, WithCaller("alertmanager"))
+	if err != nil {
+		return nil, err
+	}
+	messageId = getStringValue(data.MessageId)
+
+	fingerprints := make(map[string]bool)
+	for _, fp := range firing {
+		fingerprints[fp] = true
+	}
+	s.saveAlertCard(payload, receiveIdType, receiveId, messageId, fingerprints)
+	return &AlertNotification{MessageId: messageId, Status: payload.Status}, nil
+}
+
+// saveAlertCard 记录分组当前对应的卡片
+func (s *FeishuService) saveAlertCard(payload *AlertmanagerPayload, receiveIdType, receiveId, messageId string, fingerprints map[string]bool) {
+	list := make([]string, 0, len(fingerprints))
+	for fp := range fingerprints {
+		list = append(list, fp)
+	}
+	sort.Strings(list)
+	bs, _ := json.Marshal(list)
+
+	_, err := s.db.Exec(`
+		INSERT INTO alert_cards (group_key, receive_id_type, receive_id, message_id, status, fingerprints)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (group_key, receive_id_type, receive_id) DO UPDATE SET
+			message_id = excluded.message_id,
+			status = excluded.status,
+			fingerprints = excluded.fingerprints,
+			updated_at = CURRENT_TIMESTAMP
+	`, payload.GroupKey, receiveIdType, receiveId, messageId, payload.Status, string(bs))
+	if err != nil {
+		log.Printf("Failed to save alert card of group %s: %v", payload.GroupKey, err)
+	}
+}
+
+// firingFingerprints 返回触发中告警的指纹，旧版本 Alertmanager 没有指纹时使用标签代替
+func firingFingerprints(payload *AlertmanagerPayload) []string {
+	var fingerprints []string
+	for _, alert := range payload.Alerts {
+		if alert.Status != AlertStatusFiring {
+			continue
+		}
+		fp := alert.Fingerprint
+		if fp == "" {
+			fp = formatLabelMatchers(alert.Labels)
+		}
+		fingerprints = append(fingerprints, fp)
+	}
+	return fingerprints
+}
+
+func allKnown(fingerprints []string, known map[string]bool) bool {
+	for _, fp := range fingerprints {
+		if !known[fp] {
+			return false
+		}
+	}
+	return true
+}
+
+// buildAlertCard 渲染告警分组卡片：分组标签、触发中与已恢复的告警、静默链接
+func buildAlertCard(payload *AlertmanagerPayload) *Card {
+	var firing, resolved []*AlertmanagerAlert
+	for _, alert := range payload.Alerts {
+		if alert.Status == AlertStatusResolved {
+			resolved = append(resolved, alert)
+		} else {
+			firing = append(firing, alert)
+		}
+	}
+
+	template := CardTemplateGreen
+	if len(firing) > 0 {
+		template = alertSeverityTemplate(firing)
+	}
+	builder := NewCardBuilder().Header(alertCardTitle(payload, len(firing)), template)
+
+	if len(payload.GroupLabels) > 0 {
+		var fields []*CardField
+		for _, key := range sortedKeys(payload.GroupLabels) {
+			fields = append(fields, NewCardField(fmt.Sprintf("**%s**\n%s", key, payload.GroupLabels[key]), true))
+		}
+		builder.Fields(fields...)
+	}
+	if summary := payload.CommonAnnotations["summary"]; summary != "" {
+		builder.Markdown(summary)
+	}
+
+	writeAlerts := func(title string, alerts []*AlertmanagerAlert) {
+		if len(alerts) == 0 {
+			return
+		}
+		builder.Divider().Markdown(fmt.Sprintf("**%s（%d）**", title, len(alerts)))
+		for i, alert := range alerts {
+			if i == maxAlertsPerCard {
+				builder.Markdown(fmt.Sprintf("另有 %d 条告警未展示", len(alerts)-maxAlertsPerCard))
+				break
+			}
+			builder.Markdown(alertMarkdown(alert, payload))
+		}
+	}
+	writeAlerts("触发中", firing)
+	writeAlerts("已恢复", resolved)
+	if payload.TruncatedAlerts > 0 {
+		builder.Markdown(fmt.Sprintf("Alertmanager 截断了 %d 条告警", payload.TruncatedAlerts))
+	}
+
+	var buttons []*CardButton
+	if payload.ExternalURL != "" {
+		if len(firing) > 0 {
+			buttons = append(buttons, NewCardURLButton("静默", alertSilenceURL(payload), "danger"))
+		}
+		buttons = append(buttons, NewCardURLButton("打开 Alertmanager", payload.ExternalURL, "default"))
+	}
+	if len(buttons) > 0 {
+		builder.Buttons(buttons...)
+	}
+
+	builder.Note(fmt.Sprintf("接收器 %s · 更新于 %s", defaultString(payload.Receiver, "-"), time.Now().Format("2006-01-02 15:04:05")))
+	return builder.Build()
+}
+
+// alertCardTitle 与 Alertmanager 默认通知标题一致，如 "[FIRING:2] HighLatency"
+func alertCardTitle(payload *AlertmanagerPayload, firing int) string {
+	name := payload.GroupLabels["alertname"]
+	if name == "" {
+		name = payload.CommonLabels["alertname"]
+	}
+	if name == "" {
+		name = defaultString(payload.Receiver, "告警")
+	}
+	if firing == 0 {
+		return "[RESOLVED] " + name
+	}
+	return fmt.Sprintf("[FIRING:%d] %s", firing, name)
+}
+
+// alertMarkdown 单条告警的内容：名称与摘要、分组以外的标签、开始与恢复时间
+func alertMarkdown(alert *AlertmanagerAlert, payload *AlertmanagerPayload) string {
+	var b strings.Builder
+	b.WriteString("**" + defaultString(alert.Labels["alertname"], "-") + "**")
+	if text := alert.Annotations["summary"]; text != "" {
+		b.WriteString(" " + text)
+	} else if text := alert.Annotations["description"]; text != "" {
+		b.WriteString(" " + text)
+	}
+
+	var labels []string
+	for _, key := range sortedKeys(alert.Labels) {
+		if _, grouped := payload.GroupLabels[key]; grouped || key == "alertname" {
+			continue
+		}
+		labels = append(labels, fmt.Sprintf("`%s=%s`", key, alert.Labels[key]))
+	}
+	if len(labels) > 0 {
+		b.WriteString("\n" + strings.Join(labels, " "))
+	}
+
+	b.WriteString("\n开始于 " + formatAlertTime(alert.StartsAt))
+	if alert.Status == AlertStatusResolved {
+		b.WriteString("，恢复于 " + formatAlertTime(alert.EndsAt))
+	}
+	if alert.GeneratorURL != "" {
+		b.WriteString(fmt.Sprintf("  [查看](%s)", alert.GeneratorURL))
+	}
+	return b.String()
+}
+
+// alertSeverityTemplate 按触发中告警的最高级别选择卡片颜色
+func alertSeverityTemplate(alerts []*AlertmanagerAlert) string {
+	level := 0
+	for _, alert := range alerts {
+		switch strings.ToLower(alert.Labels["severity"]) {
+		case "critical", "fatal", "emergency", "page", "error", "p0", "p1":
+			level = max(level, 3)
+		case "warning", "warn", "p2":
+			level = max(level, 2)
+		case "info", "notice", "p3", "p4":
+			level = max(level, 1)
+		default:
+			level = max(level, 2)
+		}
+	}
+	switch level {
+	case 3:
+		return CardTemplateRed
+	case 1:
+		return CardTemplateBlue
+	default:
+		return CardTemplateOrange
+	}
+}
+
+// alertSilenceURL 在 Alertmanager 中按分组标签创建静默的链接
+func alertSilenceURL(payload *AlertmanagerPayload) string {
+	labels := payload.GroupLabels
+	if len(labels) == 0 {
+		labels = payload.CommonLabels
+	}
+	return strings.TrimRight(payload.ExternalURL, "/") + "/#/silences/new?filter=" + url.QueryEscape(formatLabelMatchers(labels))
+}
+
+// formatLabelMatchers 将标签格式化为匹配器，如 {alertname="HighLatency",job="api"}
+func formatLabelMatchers(labels map[string]string) string {
+	matchers := make([]string, 0, len(labels))
+	for _, key := range sortedKeys(labels) {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", key, labels[key]))
+	}
+	return "{" + strings.Join(matchers, ",") + "}"
+}
+
+func formatAlertTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// 批量发送的默认与最大并发数
+const (
+	DefaultBatchConcurrency = 5
+	MaxBatchConcurrency     = 20
+)
+
+// 支持通过 SendRawMessage 发送的消息类型
+var rawMessageTypes = map[string]bool{
+	"text":        true,
+	"post":        true,
+	"image":       true,
+	"file":        true,
+	"audio":       true,
+	"media":       true,
+	"sticker":     true,
+	"interactive": true,
+	"share_chat":  true,
+	"share_user":  true,
+}
+
+// BatchReceiver 批量发送的接收者，receive_id_type 可以为 mobile 或 email
+type BatchReceiver struct {
+	ReceiveIdType string `json:"receive_id_type"`
+	ReceiveId     string `json:"receive_id"`
+}
+
+// BatchResult 单个接收者的发送结果
+type BatchResult struct {
+	ReceiveIdType string `json:"receive_id_type"`
+	ReceiveId     string `json:"receive_id"`
+	Success       bool   `json:"success"`
+	MessageId     string `json:"message_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// BroadcastRequest 批量发送消息接口的接收范围，用于部门或全员广播
+type BroadcastRequest struct {
+	DepartmentIds []string `json:"department_ids,omitempty"`
+	OpenIds       []string `json:"open_ids,omitempty"`
+	UserIds       []string `json:"user_ids,omitempty"`
+	UnionIds      []string `json:"union_ids,omitempty"`
+}
+
+// BroadcastResult 批量发送消息接口的返回结果
+type BroadcastResult struct {
+	MessageId            string   `json:"message_id"`
+	InvalidDepartmentIds []string `json:"invalid_department_ids,omitempty"`
+	InvalidOpenIds       []string `json:"invalid_open_ids,omitempty"`
+	InvalidUserIds       []string `json:"invalid_user_ids,omitempty"`
+	InvalidUnionIds      []string `json:"invalid_union_ids,omitempty"`
+}
+
+// SendRawMessage 发送指定类型的消息，content 为飞书消息 content 的 JSON
+func (s *FeishuService) SendRawMessage(receiveIdType, receiveId, msgType, content string, opts ...SendOption) (*larkim.CreateMessageRespData, error) {
+	if !rawMessageTypes[msgType] {
+		return nil, fmt.Errorf("unsupported msg_type: %s", msgType)
+	}
+	if !json.Valid([]byte(content)) {
+		return nil, fmt.Errorf("content is not valid json")
+	}
+
+	data, err := s.createMessage(receiveIdType, receiveId, msgType, json.RawMessage(content), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("send %s message failed: %w", msgType, err)
+	}
+	return data, nil
+}
+
+// BatchSendMessage 以有限并发向多个接收者发送同一条消息，结果顺序与接收者顺序一致
+// 设置了幂等键时，每个接收者使用由幂等键和接收者派生的独立幂等键
+func (s *FeishuService) BatchSendMessage(receivers []*BatchReceiver, msgType, content string, concurrency int, opts ...SendOption) []*BatchResult {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	if concurrency > MaxBatchConcurrency {
+		concurrency = MaxBatchConcurrency
+	}
+
+	// 预先批量解析手机号和邮箱，避免逐个调用通讯录接口
+	var mobiles, emails []string
+	for _, receiver := range receivers {
+		switch receiver.ReceiveIdType {
+		case ReceiveIdTypeMobile:
+			mobiles = append(mobiles, receiver.ReceiveId)
+		case ReceiveIdTypeEmail:
+			emails = append(emails, receiver.ReceiveId)
+		}
+	}
+	if len(mobiles) > 0 || len(emails) > 0 {
+		if _, err := s.ResolveUserIds(mobiles, emails); err != nil {
+			log.Printf("Failed to resolve batch recipients: %v", err)
+		}
+	}
+
+	options := applySendOptions(opts)
+	results := make([]*BatchResult, len(receivers))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, receiver := range receivers {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, receiver *BatchReceiver) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := &BatchResult{ReceiveIdType: receiver.ReceiveIdType, ReceiveId: receiver.ReceiveId}
+			results[i] = result
+
+			receiverOpts := append([]SendOption{}, opts...)
+			if options.Uuid != "" {
+				receiverOpts = append(receiverOpts, WithUuid(batchUuid(options.Uuid, receiver)))
+			}
+
+			data, err := s.SendRawMessage(receiver.ReceiveIdType, receiver.ReceiveId, msgType, content, receiverOpts...)
+			if err != nil {
+				result.Error = err.Error()
+				return
+			}
+			result.Success = true
+			result.MessageId = getStringValue(data.MessageId)
+		}(i, receiver)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// batchUuid 由批量幂等键和接收者派生单个接收者的幂等键
+func batchUuid(uuid string, receiver *BatchReceiver) string {
+	sum := sha256.Sum256([]byte(uuid + "|" + receiver.ReceiveIdType + "|" + receiver.ReceiveId))
+	return hex.EncodeToString(sum[:16])
+}
+
+// broadcastUuid 由批量幂等键派生广播的幂等键
+func broadcastUuid(uuid string) string {
+	sum := sha256.Sum256([]byte(uuid + "|broadcast"))
+	return hex.EncodeToString(sum[:16])
+}
+
+// BroadcastMessage 调用批量发送消息接口，向部门或用户列表广播消息
+// 该接口为异步发送，仅返回批量消息ID和无效的接收者
+// 批量发送接口不支持幂等键，设置了幂等键时在本地去重，重复请求返回首次广播的结果
+func (s *FeishuService) BroadcastMessage(req *BroadcastRequest, msgType, content string, opts ...SendOption) (*BroadcastResult, error) {
+	if len(req.DepartmentIds)+len(req.OpenIds)+len(req.UserIds)+len(req.UnionIds) == 0 {
+		return nil, fmt.Errorf("broadcast receivers are empty")
+	}
+
+	options := applySendOptions(opts)
+	uuid := ""
+	if options.Uuid != "" {
+		uuid = broadcastUuid(options.Uuid)
+		cached := &BroadcastResult{}
+		if s.loadCachedResponse(uuid, cached) {
+			return cached, nil
+		}
+	}
+
+	start := time.Now()
+	result, err := s.broadcastMessage(req, msgType, content)
+
+	receivers, _ := json.Marshal(req)
+	entry := &messageLogEntry{
+		receiveIdType: "broadcast",
+		receiveId:     string(receivers),
+		msgType:       msgType,
+		content:       content,
+		caller:        options.Caller,
+		latency:       time.Since(start),
+		err:           err,
+	}
+	if result != nil {
+		entry.data = &larkim.CreateMessageRespData{MessageId: &result.MessageId}
+	}
+	s.logMessage(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if uuid != "" {
+		s.storeCachedResponse(uuid, result.MessageId, result)
+	}
+	return result, nil
+}
+
+// broadcastMessage 调用批量发送消息接口
+func (s *FeishuService) broadcastMessage(req *BroadcastRequest, msgType, content string) (*BroadcastResult, error) {
+
+	body := map[string]interface{}{
+		"msg_type": msgType,
+	}
+	if len(req.DepartmentIds) > 0 {
+		body["department_ids"] = req.DepartmentIds
+	}
+	if len(req.OpenIds) > 0 {
+		body["open_ids"] = req.OpenIds
+	}
+	if len(req.UserIds) > 0 {
+		body["user_ids"] = req.UserIds
+	}
+	if len(req.UnionIds) > 0 {
+		body["union_ids"] = req.UnionIds
+	}
+
+	// 批量发送接口中卡片放在 card 字段，富文本需要包在 post 字段中
+	switch msgType {
+	case "interactive":
+		body["card"] = json.RawMessage(content)
+	case "post":
+		body["content"] = map[string]interface{}{"post": json.RawMessage(content)}
+	default:
+		body["content"] = json.RawMessage(content)
+	}
+
+	resp, err := s.client.Post(context.Background(), "/open-apis/message/v4/batch_send/", body, larkcore.AccessTokenTypeTenant)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Code int              `json:"code"`
+		Msg  string           `json:"msg"`
+		Data *BroadcastResult `json:"data"`
+	}
+	if err := json.Unmarshal(resp.RawBody, &result); err != nil {
+		return nil, fmt.Errorf("parse batch send response failed: %v", err)
+	}
+	if result.Code != 0 {
+		return nil, fmt.Errorf("batch send message failed: %w", &FeishuError{Code: result.Code, Msg: result.Msg})
+	}
+	if result.Data == nil {
+		result.Data = &BroadcastResult{}
+	}
+	return result.Data, nil
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// 自定义机器人要求签名时间戳与当前时间相差不超过 1 小时
+const botHookSignWindow = time.Hour
+
+// 自定义 token 只允许字母、数字、下划线和中划线，便于沿用原自定义机器人的 token
+var botHookTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8,64}$`)
+
+var (
+	// ErrBotHookNotFound token 不存在
+	ErrBotHookNotFound = errors.New("incoming webhook access token invalid")
+	// ErrBotHookSignMismatch 签名错误或时间戳超出有效期
+	ErrBotHookSignMismatch = errors.New("sign match fail or timestamp is not within one hour from current time")
+	// ErrBotHookBadRequest 消息格式错误
+	ErrBotHookBadRequest = errors.New("bad request")
+)
+
+// BotHook 自定义机器人兼容地址，推送到 /hook/:token 的消息由应用机器人发送给对应的群或用户
+type BotHook struct {
+	Token         string    `json:"token"`
+	Name          string    `json:"name"`
+	ReceiveIdType string    `json:"receive_id_type" binding:"required"`
+	ReceiveId     string    `json:"receive_id" binding:"required"`
+	Secret        string    `json:"secret,omitempty"` // 签名校验密钥，仅在创建时返回
+	HasSecret     bool      `json:"has_secret"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// BotHookMessage 自定义机器人的消息格式，timestamp 兼容字符串和数字
+type BotHookMessage struct {
+	Timestamp json.RawMessage `json:"timestamp"`
+	Sign      string          `json:"sign"`
+	MsgType   string          `json:"msg_type"`
+	Content   json.RawMessage `json:"content"`
+	Card      json.RawMessage `json:"card"` // 消息卡片在 card 字段中，不在 content 中
+}
+
+// CreateBotHook 创建自定义机器人兼容地址，未指定 token 时随机生成
+func (s *FeishuService) CreateBotHook(hook *BotHook) error {
+	if s.db == nil {
+		return fmt.Errorf("bot hook store is not configured")
+	}
+	if hook.Token == "" {
+		hook.Token = newBotHookToken()
+	} else if !botHookTokenPattern.MatchString(hook.Token) {
+		return fmt.Errorf("invalid token: %q", hook.Token)
+	}
+
+	err := s.db.QueryRow(`
+		INSERT INTO bot_hooks (token, name, receive_id_type, receive_id, secret)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING created_at
+	`, hook.Token, hook.Name, hook.ReceiveIdType, hook.ReceiveId, hook.Secret).Scan(&hook.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return fmt.Errorf("token %s already exists", hook.Token)
+		}
+		return err
+	}
+	hook.HasSecret = hook.Secret != ""
+	return nil
+}
+
+// ListBotHooks 获取所有自定义机器人兼容地址，不返回签名密钥
+func (s *FeishuService) ListBotHooks() ([]*BotHook, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("bot hook store is not configured")
+	}
+
+	rows, err := s.db.Query(`
+		SELECT token, name, receive_id_type, receive_id, secret, created_at
+		FROM bot_hooks ORDER BY created_at, token
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []*BotHook{}
+	for rows.Next() {
+		hook, err := scanBotHook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hook.Secret = ""
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// DeleteBotHook 删除自定义机器人兼容地址，不存在时返回 false
+func (s *FeishuService) DeleteBotHook(token string) (bool, error) {
+	if s.db == nil {
+		return false, fmt.Errorf("bot hook store is not configured")
+	}
+
+	result, err := s.db.Exec(`DELETE FROM bot_hooks WHERE token = ?`, token)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// SendBotHookMessage 校验 token 与签名后，将自定义机器人格式的消息转发给 token 对应的接收者
+func (s *FeishuService) SendBotHookMessage(token string, msg *BotHookMessage) (*larkim.CreateMessageRespData, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("bot hook store is not configured")
+	}
+
+	hook, err := scanBotHook(s.db.QueryRow(`
+		SELECT token, name, receive_id_type, receive_id, secret, created_at
+		FROM bot_hooks WHERE token = ?
+	`, token))
+	if err == sql.ErrNoRows {
+		return nil, ErrBotHookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if hook.Secret != "" {
+		if err := verifyBotHookSign(hook.Secret, msg, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	msgType, content, err := botHookContent(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	caller := "hook:" + hook.Name
+	if hook.Name == "" {
+		caller = "hook:" + hook.Token[:8]
+	}
+	return s.SendRawMessage(hook.ReceiveIdType, hook.ReceiveId, msgType, content, WithCaller(caller))
+}
+
+// botHookContent 将自定义机器人的消息内容转换为发送消息接口的 content
+func botHookContent(msg *BotHookMessage) (msgType, content string, err error) {
+	switch msg.MsgType {
+	case "text", "image":
+		// 与发送消息接口的 content 格式一致，如 {"text":"..."}、{"image_key":"..."}
+		if len(msg.Content) == 0 {
+			return "", "", fmt.Errorf("%w: content is required", ErrBotHookBadRequest)
+		}
+		return msg.MsgType, string(msg.Content), nil
+	case "post":
+		var c struct {
+			Post json.RawMessage `json:"post"`
+		}
+		if err := json.Unmarshal(msg.Content, &c); err != nil || len(c.Post) == 0 {
+			return "", "", fmt.Errorf("%w: content.post is required", ErrBotHookBadRequest)
+		}
+		return "post", string(c.Post), nil
+	case "share_chat":
+		var c struct {
+			ShareChatId string `json:"share_chat_id"`
+		}
+		if err := json.Unmarshal(msg.Content, &c); err != nil || c.ShareChatId == "" {
+			return "", "", fmt.Errorf("%w: content.share_chat_id is required", ErrBotHookBadRequest)
+		}
+		bs, _ := json.Marshal(map[string]string{"chat_id": c.ShareChatId})
+		return "share_chat", string(bs), nil
+	case "interactive":
+		card := msg.Card
+		if len(card) == 0 {
+			card = msg.Content
+		}
+		if len(card) == 0 {
+			return "", "", fmt.Errorf("%w: card is required", ErrBotHookBadRequest)
+		}
+		return "interactive", string(card), nil
+	default:
+		return "", "", fmt.Errorf("%w: unsupported msg_type %s", ErrBotHookBadRequest, msg.MsgType)
+	}
+}
+
+// verifyBotHookSign 校验自定义机器人签名：以 "timestamp\nsecret" 为密钥对空内容计算 HmacSHA256 后 Base64 编码
+func verifyBotHookSign(secret string, msg *BotHookMessage, now time.Time) error {
+	timestamp := strings.Trim(string(msg.Timestamp), `"`)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || msg.Sign == "" {
+		return ErrBotHookSignMismatch
+	}
+	if diff := now.Sub(time.Unix(seconds, 0)); diff > botHookSignWindow || diff < -botHookSignWindow {
+		return ErrBotHookSignMismatch
+	}
+
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(msg.Sign)) {
+		return ErrBotHookSignMismatch
+	}
+	return nil
+}
+
+// scanBotHook 读取一行 bot_hooks 记录
+func scanBotHook(row interface{ Scan(...interface{}) error }) (*BotHook, error) {
+	hook := &BotHook{}
+	var name, secret sql.NullString
+	if err := row.Scan(&hook.Token, &name, &hook.ReceiveIdType, &hook.ReceiveId, &secret, &hook.CreatedAt); err != nil {
+		return nil, err
+	}
+	hook.Name = name.String
+	hook.Secret = secret.String
+	hook.HasSecret = secret.String != ""
+	return hook, nil
+}
+
+// newBotHookToken 生成与自定义机器人格式一致的 UUID 形式 token
+func newBotHookToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return NewOutboxId()
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
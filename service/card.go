@@ -0,0 +1,434 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// 卡片标题栏颜色模板
+const (
+	CardTemplateBlue      = "blue"
+	CardTemplateWathet    = "wathet"
+	CardTemplateTurquoise = "turquoise"
+	CardTemplateGreen     = "green"
+	CardTemplateYellow    = "yellow"
+	CardTemplateOrange    = "orange"
+	CardTemplateRed       = "red"
+	CardTemplateCarmine   = "carmine"
+	CardTemplateViolet    = "violet"
+	CardTemplatePurple    = "purple"
+	CardTemplateIndigo    = "indigo"
+	CardTemplateGrey      = "grey"
+)
+
+// 卡片按钮样式
+const (
+	CardButtonDefault = "default"
+	CardButtonPrimary = "primary"
+	CardButtonDanger  = "danger"
+)
+
+// Card 消息卡片，序列化后即为 interactive 消息的 content
+type Card struct {
+	Config   *CardConfig   `json:"config,omitempty"`
+	Header   *CardHeader   `json:"header,omitempty"`
+	Elements []CardElement `json:"elements"`
+}
+
+// CardConfig 卡片全局配置
+type CardConfig struct {
+	WideScreenMode bool `json:"wide_screen_mode"`
+	UpdateMulti    bool `json:"update_multi"`
+}
+
+// CardHeader 卡片标题栏
+type CardHeader struct {
+	Title    *CardText `json:"title"`
+	Template string    `json:"template,omitempty"`
+}
+
+// CardText 卡片文本对象，Tag 取值 plain_text 或 lark_md
+type CardText struct {
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+}
+
+// CardElement 卡片内容元素
+type CardElement interface {
+	cardTag() string
+}
+
+// CardDiv 文本模块，可包含正文和多列字段
+type CardDiv struct {
+	Tag    string       `json:"tag"`
+	Text   *CardText    `json:"text,omitempty"`
+	Fields []*CardField `json:"fields,omitempty"`
+}
+
+// CardField 文本模块中的字段
+type CardField struct {
+	IsShort bool      `json:"is_short"`
+	Text    *CardText `json:"text"`
+}
+
+// CardMarkdown Markdown 模块
+type CardMarkdown struct {
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+}
+
+// CardDivider 分割线
+type CardDivider struct {
+	Tag string `json:"tag"`
+}
+
+// CardImage 图片模块
+type CardImage struct {
+	Tag    string    `json:"tag"`
+	ImgKey string    `json:"img_key"`
+	Alt    *CardText `json:"alt"`
+	Title  *CardText `json:"title,omitempty"`
+}
+
+// CardAction 交互模块，包含一组按钮
+type CardAction struct {
+	Tag     string        `json:"tag"`
+	Actions []*CardButton `json:"actions"`
+}
+
+// CardButton 按钮，设置 URL 时为跳转按钮，否则点击时回传 Value
+type CardButton struct {
+	Tag   string                 `json:"tag"`
+	Text  *CardText              `json:"text"`
+	Type  string                 `json:"type,omitempty"`
+	URL   string                 `json:"url,omitempty"`
+	Value map[string]interface{} `json:"value,omitempty"`
+}
+
+// CardNote 备注模块
+type CardNote struct {
+	Tag      string      `json:"tag"`
+	Elements []*CardText `json:"elements"`
+}
+
+// CardColumnSet 多列布局
+type CardColumnSet struct {
+	Tag             string        `json:"tag"`
+	FlexMode        string        `json:"flex_mode"`
+	BackgroundStyle string        `json:"background_style,omitempty"`
+	Columns         []*CardColumn `json:"columns"`
+}
+
+// CardColumn 多列布局中的单列
+type CardColumn struct {
+	Tag           string        `json:"tag"`
+	Width         string        `json:"width"`
+	Weight        int           `json:"weight,omitempty"`
+	VerticalAlign string        `json:"vertical_align,omitempty"`
+	Elements      []CardElement `json:"elements"`
+}
+
+func (e *CardDiv) cardTag() string       { return e.Tag }
+func (e *CardMarkdown) cardTag() string  { return e.Tag }
+func (e *CardDivider) cardTag() string   { return e.Tag }
+func (e *CardImage) cardTag() string     { return e.Tag }
+func (e *CardAction) cardTag() string    { return e.Tag }
+func (e *CardNote) cardTag() string      { return e.Tag }
+func (e *CardColumnSet) cardTag() string { return e.Tag }
+
+// PlainText 构建纯文本对象
+func PlainText(content string) *CardText {
+	return &CardText{Tag: "plain_text", Content: content}
+}
+
+// LarkMd 构建 lark_md 文本对象
+func LarkMd(content string) *CardText {
+	return &CardText{Tag: "lark_md", Content: content}
+}
+
+// NewCardField 构建文本模块字段
+func NewCardField(content string, isShort bool) *CardField {
+	return &CardField{IsShort: isShort, Text: LarkMd(content)}
+}
+
+// NewCardURLButton 构建跳转链接按钮
+func NewCardURLButton(text, url, buttonType string) *CardButton {
+	return &CardButton{Tag: "button", Text: PlainText(text), Type: buttonType, URL: url}
+}
+
+// NewCardValueButton 构建回传交互按钮
+func NewCardValueButton(text, buttonType string, value map[string]interface{}) *CardButton {
+	return &CardButton{Tag: "button", Text: PlainText(text), Type: buttonType, Value: value}
+}
+
+// NewCardColumn 构建按权重分配宽度的列
+func NewCardColumn(weight int, elements ...CardElement) *CardColumn {
+	return &CardColumn{
+		Tag:           "column",
+		Width:         "weighted",
+		Weight:        weight,
+		VerticalAlign: "top",
+		Elements:      elements,
+	}
+}
+
+// CardBuilder 消息卡片构建器
+type CardBuilder struct {
+	card *Card
+}
+
+// NewCardBuilder 创建卡片构建器，默认开启宽屏模式和共享卡片更新
+func NewCardBuilder() *CardBuilder {
+	return &CardBuilder{
+		card: &Card{
+			Config:   &CardConfig{WideScreenMode: true, UpdateMulti: true},
+			Elements: []CardElement{},
+		},
+	}
+}
+
+// Header 设置标题栏
+func (b *CardBuilder) Header(title, template string) *CardBuilder {
+	b.card.Header = &CardHeader{Title: PlainText(title), Template: template}
+	return b
+}
+
+// Markdown 添加 Markdown 模块
+func (b *CardBuilder) Markdown(content string) *CardBuilder {
+	return b.Element(&CardMarkdown{Tag: "markdown", Content: content})
+}
+
+// Div 添加文本模块
+func (b *CardBuilder) Div(content string) *CardBuilder {
+	return b.Element(&CardDiv{Tag: "div", Text: LarkMd(content)})
+}
+
+// Fields 添加多字段文本模块
+func (b *CardBuilder) Fields(fields ...*CardField) *CardBuilder {
+	return b.Element(&CardDiv{Tag: "div", Fields: fields})
+}
+
+// Buttons 添加按钮组
+func (b *CardBuilder) Buttons(buttons ...*CardButton) *CardBuilder {
+	return b.Element(&CardAction{Tag: "action", Actions: buttons})
+}
+
+// Image 添加图片模块
+func (b *CardBuilder) Image(imageKey, alt string) *CardBuilder {
+	return b.Element(&CardImage{Tag: "img", ImgKey: imageKey, Alt: PlainText(alt)})
+}
+
+// Divider 添加分割线
+func (b *CardBuilder) Divider() *CardBuilder {
+	return b.Element(&CardDivider{Tag: "hr"})
+}
+
+// Note 添加备注模块
+func (b *CardBuilder) Note(contents ...string) *CardBuilder {
+	note := &CardNote{Tag: "note"}
+	for _, content := range contents {
+		note.Elements = append(note.Elements, LarkMd(content))
+	}
+	return b.Element(note)
+}
+
+// Columns 添加多列布局
+func (b *CardBuilder) Columns(columns ...*CardColumn) *CardBuilder {
+	return b.Element(&CardColumnSet{
+		Tag:             "column_set",
+		FlexMode:        "none",
+		BackgroundStyle: "default",
+		Columns:         columns,
+	})
+}
+
+// Element 添加任意卡片元素
+func (b *CardBuilder) Element(element CardElement) *CardBuilder {
+	b.card.Elements = append(b.card.Elements, element)
+	return b
+}
+
+// Build 返回构建好的卡片
+func (b *CardBuilder) Build() *Card {
+	return b.card
+}
+
+// CardSpec 卡片构建器的 JSON 描述形式，便于通过接口直接传入
+type CardSpec struct {
+	Title    string             `json:"title"`
+	Template string             `json:"template"`
+	Elements []*CardElementSpec `json:"elements"`
+}
+
+// CardElementSpec 卡片元素的 JSON 描述
+// Type 取值: markdown、div、fields、buttons、image、divider、note、columns
+type CardElementSpec struct {
+	Type     string            `json:"type"`
+	Content  string            `json:"content,omitempty"`
+	Fields   []*CardFieldSpec  `json:"fields,omitempty"`
+	Buttons  []*CardButtonSpec `json:"buttons,omitempty"`
+	ImageKey string            `json:"image_key,omitempty"`
+	Alt      string            `json:"alt,omitempty"`
+	Notes    []string          `json:"notes,omitempty"`
+	Columns  []*CardColumnSpec `json:"columns,omitempty"`
+}
+
+// CardFieldSpec 字段描述
+type CardFieldSpec struct {
+	Content string `json:"content"`
+	Short   bool   `json:"short"`
+}
+
+// CardButtonSpec 按钮描述
+type CardButtonSpec struct {
+	Text  string                 `json:"text"`
+	Type  string                 `json:"type"`
+	URL   string                 `json:"url"`
+	Value map[string]interface{} `json:"value"`
+}
+
+// CardColumnSpec 列描述
+type CardColumnSpec struct {
+	Weight   int                `json:"weight"`
+	Elements []*CardElementSpec `json:"elements"`
+}
+
+// Build 将 JSON 描述转换为卡片
+func (spec *CardSpec) Build() (*Card, error) {
+	builder := NewCardBuilder()
+	if spec.Title != "" {
+		builder.Header(spec.Title, spec.Template)
+	}
+
+	for i, elemSpec := range spec.Elements {
+		elem, err := elemSpec.build()
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %v", i, err)
+		}
+		builder.Element(elem)
+	}
+
+	card := builder.Build()
+	if card.Header == nil && len(card.Elements) == 0 {
+		return nil, fmt.Errorf("card has neither title nor elements")
+	}
+	return card, nil
+}
+
+func (spec *CardElementSpec) build() (CardElement, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("element is empty")
+	}
+
+	switch spec.Type {
+	case "markdown":
+		return &CardMarkdown{Tag: "markdown", Content: spec.Content}, nil
+	case "div":
+		return &CardDiv{Tag: "div", Text: LarkMd(spec.Content)}, nil
+	case "fields":
+		if len(spec.Fields) == 0 {
+			return nil, fmt.Errorf("fields element requires fields")
+		}
+		div := &CardDiv{Tag: "div"}
+		for i, f := range spec.Fields {
+			if f == nil {
+				return nil, fmt.Errorf("field %d is empty", i)
+			}
+			div.Fields = append(div.Fields, NewCardField(f.Content, f.Short))
+		}
+		return div, nil
+	case "buttons":
+		if len(spec.Buttons) == 0 {
+			return nil, fmt.Errorf("buttons element requires buttons")
+		}
+		action := &CardAction{Tag: "action"}
+		for i, btn := range spec.Buttons {
+			if btn == nil {
+				return nil, fmt.Errorf("button %d is empty", i)
+			}
+			if btn.Text == "" {
+				return nil, fmt.Errorf("button %d requires text", i)
+			}
+			if btn.URL != "" {
+				action.Actions = append(action.Actions, NewCardURLButton(btn.Text, btn.URL, btn.Type))
+			} else {
+				action.Actions = append(action.Actions, NewCardValueButton(btn.Text, btn.Type, btn.Value))
+			}
+		}
+		return action, nil
+	case "image":
+		if spec.ImageKey == "" {
+			return nil, fmt.Errorf("image element requires image_key")
+		}
+		return &CardImage{Tag: "img", ImgKey: spec.ImageKey, Alt: PlainText(spec.Alt)}, nil
+	case "divider":
+		return &CardDivider{Tag: "hr"}, nil
+	case "note":
+		note := &CardNote{Tag: "note"}
+		notes := spec.Notes
+		if len(notes) == 0 && spec.Content != "" {
+			notes = []string{spec.Content}
+		}
+		if len(notes) == 0 {
+			return nil, fmt.Errorf("note element requires notes or content")
+		}
+		for _, n := range notes {
+			note.Elements = append(note.Elements, LarkMd(n))
+		}
+		return note, nil
+	case "columns":
+		if len(spec.Columns) == 0 {
+			return nil, fmt.Errorf("columns element requires columns")
+		}
+		set := &CardColumnSet{Tag: "column_set", FlexMode: "none", BackgroundStyle: "default"}
+		for i, colSpec := range spec.Columns {
+			if colSpec == nil || len(colSpec.Elements) == 0 {
+				return nil, fmt.Errorf("column %d is empty", i)
+			}
+			weight := colSpec.Weight
+			if weight <= 0 {
+				weight = 1
+			}
+			col := NewCardColumn(weight)
+			for j, child := range colSpec.Elements {
+				elem, err := child.build()
+				if err != nil {
+					return nil, fmt.Errorf("column %d element %d: %v", i, j, err)
+				}
+				col.Elements = append(col.Elements, elem)
+			}
+			set.Columns = append(set.Columns, col)
+		}
+		return set, nil
+	default:
+		return nil, fmt.Errorf("unsupported element type: %s", spec.Type)
+	}
+}
+
+// SendCardMessage 发送由构建器生成的消息卡片
+func (s *FeishuService) SendCardMessage(receiveIdType, receiveId string, card *Card, opts ...SendOption) (*larkim.CreateMessageRespData, error) {
+	if card == nil {
+		return nil, fmt.Errorf("card is required")
+	}
+
+	data, err := s.createMessage(receiveIdType, receiveId, "interactive", card, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("send card message failed: %w", err)
+	}
+	return data, nil
+}
+
+// SendRawCardMessage 发送原始 JSON 格式的消息卡片
+func (s *FeishuService) SendRawCardMessage(receiveIdType, receiveId, cardJSON string, opts ...SendOption) (*larkim.CreateMessageRespData, error) {
+	if !json.Valid([]byte(cardJSON)) {
+		return nil, fmt.Errorf("card is not valid json")
+	}
+
+	data, err := s.createMessage(receiveIdType, receiveId, "interactive", json.RawMessage(cardJSON), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("send card message failed: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	larkcard "github.com/larksuite/oapi-sdk-go/v3/card"
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkevent "github.com/larksuite/oapi-sdk-go/v3/event"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher/callback"
+)
+
+// 卡片按钮回传值中标识操作的字段
+const CardActionIdKey = "action_id"
+
+// 卡片操作提示的类型
+const (
+	ToastInfo    = "info"
+	ToastSuccess = "success"
+	ToastWarning = "warning"
+	ToastError   = "error"
+)
+
+// CardActionContext 卡片操作的上下文
+type CardActionContext struct {
+	ActionId   string
+	Value      map[string]interface{} // 按钮的回传值，包含 action_id
+	FormValue  map[string]interface{} // 表单容器提交的值
+	Option     string                 // 下拉选择、日期选择等组件选中的值
+	InputValue string                 // 输入框的值
+	MessageId  string
+	ChatId     string
+	Operator   *UserInfo // 点击卡片的用户，通讯录缓存中存在时包含姓名等信息
+	Token      string    // 用于延时更新卡片的凭证
+}
+
+// StringValue 获取回传值中的字符串字段
+func (c *CardActionContext) StringValue(key string) string {
+	if v, ok := c.Value[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// CardActionResult 卡片操作的响应，Card 不为空时用于更新被点击的卡片
+type CardActionResult struct {
+	Toast     string
+	ToastType string // 默认为 info
+	Card      *Card
+}
+
+// CardActionHandler 卡片操作处理函数，返回 nil 时不更新卡片也不展示提示
+type CardActionHandler func(ctx *CardActionContext) (*CardActionResult, error)
+
+// cardActionRouter 按 action_id 分发卡片操作
+type cardActionRouter struct {
+	mu       sync.RWMutex
+	handlers map[string]CardActionHandler
+}
+
+func newCardActionRouter() *cardActionRouter {
+	return &cardActionRouter{handlers: make(map[string]CardActionHandler)}
+}
+
+// OnCardAction 注册卡片操作处理函数，按钮回传值中 action_id 等于 actionId 时调用
+func (s *FeishuService) OnCardAction(actionId string, handler CardActionHandler) error {
+	if actionId == "" || handler == nil {
+		return fmt.Errorf("action id and handler are required")
+	}
+
+	s.cardActions.mu.Lock()
+	defer s.cardActions.mu.Unlock()
+	if _, ok := s.cardActions.handlers[actionId]; ok {
+		return fmt.Errorf("card action %s is already registered", actionId)
+	}
+	s.cardActions.handlers[actionId] = handler
+	return nil
+}
+
+// CardCallbackHandler 返回处理卡片回传交互请求的处理器，负责 URL 校验与验签
+func (s *FeishuService) CardCallbackHandler() *larkcard.CardActionHandler {
+	return s.cardActionHandler
+}
+
+// newCardActionHandler 创建旧版卡片回传交互（请求网址配置在「消息卡片请求网址」）的处理器
+func (s *FeishuService) newCardActionHandler() *larkcard.CardActionHandler {
+	handler := larkcard.NewCardActionHandler(s.config.EventVerificationToken, s.config.EventEncryptKey,
+		func(_ context.Context, action *larkcard.CardAction) (interface{}, error) {
+			ctx := &CardActionContext{
+				MessageId: action.OpenMessageID,
+				ChatId:    action.OpenChatId,
+				Operator:  s.eventOperator(action.UserID, action.OpenID, ""),
+			}
+			if action.Action != nil {
+				ctx.Value = action.Action.Value
+				ctx.FormValue = action.Action.FormValue
+				ctx.Option = action.Action.Option
+				ctx.InputValue = action.Action.InputValue
+				ctx.ActionId = cardActionId(action.Action.Value, action.Action.Name)
+			}
+
+			result := s.handleCardAction(ctx)
+			if result == nil {
+				return nil, nil
+			}
+			// 旧版回调直接返回卡片内容即可更新卡片，无法同时展示提示
+			if result.Card != nil {
+				return result.Card, nil
+			}
+			return &larkcard.CustomResp{
+				Body: map[string]interface{}{
+					"toast": map[string]interface{}{"type": toastType(result), "content": result.Toast},
+				},
+			}, nil
+		})
+	handler.InitConfig(larkevent.WithLogLevel(larkcore.LogLevelInfo))
+	return handler
+}
+
+// onCardActionTrigger 处理新版卡片回传交互（card.action.trigger），通过事件订阅或长连接推送
+func (s *FeishuService) onCardActionTrigger(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	if event.EventV2Base == nil || event.EventV2Base.Header == nil || !s.verifyEventToken(ctx, event.EventV2Base.Header.Token) {
+		return nil, fmt.Errorf("event token mismatch")
+	}
+	if event.Event == nil {
+		return nil, nil
+	}
+
+	request := event.Event
+	actionCtx := &CardActionContext{Token: request.Token}
+	if request.Operator != nil {
+		actionCtx.Operator = s.eventOperator(getStringValue(request.Operator.UserID), request.Operator.OpenID, "")
+	} else {
+		actionCtx.Operator = &UserInfo{}
+	}
+	if request.Context != nil {
+		actionCtx.MessageId = request.Context.OpenMessageID
+		actionCtx.ChatId = request.Context.OpenChatID
+	}
+	if request.Action != nil {
+		actionCtx.Value = request.Action.Value
+		actionCtx.FormValue = request.Action.FormValue
+		actionCtx.Option = request.Action.Option
+		actionCtx.InputValue = request.Action.InputValue
+		actionCtx.ActionId = cardActionId(request.Action.Value, request.Action.Name)
+	}
+
+	result := s.handleCardAction(actionCtx)
+	if result == nil {
+		return nil, nil
+	}
+	resp := &callback.CardActionTriggerResponse{}
+	if result.Toast != "" {
+		resp.Toast = &callback.Toast{Type: toastType(result), Content: result.Toast}
+	}
+	if result.Card != nil {
+		resp.Card = &callback.Card{Type: "raw", Data: result.Card}
+	}
+	return resp, nil
+}
+
+// handleCardAction 按 action_id 调用处理函数，处理失败时以错误提示响应
+func (s *FeishuService) handleCardAction(ctx *CardActionContext) *CardActionResult {
+	s.cardActions.mu.RLock()
+	handler := s.cardActions.handlers[ctx.ActionId]
+	s.cardActions.mu.RUnlock()
+
+	if handler == nil {
+		log.Printf("No handler for card action %q (message %s)", ctx.ActionId, ctx.MessageId)
+		return &CardActionResult{Toast: "不支持的操作", ToastType: ToastError}
+	}
+
+	result, err := handler(ctx)
+	if err != nil {
+		log.Printf("Card action %s failed: %v", ctx.ActionId, err)
+		return &CardActionResult{Toast: "操作失败: " + err.Error(), ToastType: ToastError}
+	}
+	return result
+}
+
+// cardActionId 从回传值中读取 action_id，没有时使用组件名称
+func cardActionId(value map[string]interface{}, name string) string {
+	if id, ok := value[CardActionIdKey].(string); ok && id != "" {
+		return id
+	}
+	return name
+}
+
+func toastType(result *CardActionResult) string {
+	return defaultString(result.ToastType, ToastInfo)
+}
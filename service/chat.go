@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// 创建群和拉人入群接口单次最多支持的用户数
+const chatMemberBatchSize = 50
+
+// ChatSpec 创建群的参数，群主与成员可以使用任意用户标识类型
+type ChatSpec struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Owner       *UserIdentifier   `json:"owner"`
+	Members     []*UserIdentifier `json:"members"`
+	ChatType    string            `json:"chat_type"` // private（默认）或 public
+	Uuid        string            `json:"uuid"`      // 创建群的幂等键，相同 uuid 10 小时内只创建一个群
+}
+
+// ChatInfo 创建群的结果
+type ChatInfo struct {
+	ChatId      string `json:"chat_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	OwnerId     string `json:"owner_id"`
+	// 群已创建但分批拉人失败时的错误与未拉入的 user_id，可以通过拉人入群接口重试
+	MembersError    string   `json:"members_error,omitempty"`
+	NotAddedUserIds []string `json:"not_added_user_ids,omitempty"`
+	*ChatMembersResult
+}
+
+// ChatMembersResult 拉人入群或移出群的结果
+type ChatMembersResult struct {
+	Unresolved            []*UserIdentifier `json:"unresolved,omitempty"`
+	InvalidIdList         []string          `json:"invalid_id_list,omitempty"`
+	NotExistedIdList      []string          `json:"not_existed_id_list,omitempty"`
+	PendingApprovalIdList []string          `json:"pending_approval_id_list,omitempty"`
+}
+
+// resolveMemberUserIds 将用户标识转换为 user_id，无法转换的标识单独返回
+func (s *FeishuService) resolveMemberUserIds(members []*UserIdentifier) ([]string, []*UserIdentifier, error) {
+	var userIds []string
+	var unresolved []*UserIdentifier
+	var lookups []*UserIdentifier
+	seen := make(map[string]bool)
+
+	add := func(userId string) {
+		if !seen[userId] {
+			seen[userId] = true
+			userIds = append(userIds, userId)
+		}
+	}
+
+	for _, member := range members {
+		if member.IdType == "user_id" {
+			add(member.Id)
+			continue
+		}
+		if !IsUserIdentifierType(member.IdType) {
+			return nil, nil, fmt.Errorf("unsupported id_type: %s", member.IdType)
+		}
+		lookups = append(lookups, member)
+	}
+
+	if len(lookups) > 0 {
+		results, err := s.ResolveUsers(lookups)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, result := range results {
+			if !result.Found || result.User.UserID == "" {
+				unresolved = append(unresolved, lookups[i])
+				continue
+			}
+			add(result.User.UserID)
+		}
+	}
+
+	return userIds, unresolved, nil
+}
+
+// CreateChat 创建群，成员超过单次上限时分批拉入
+// 群创建后拉人失败时不返回错误，失败原因与未拉入的成员记录在结果中
+func (s *FeishuService) CreateChat(spec *ChatSpec) (*ChatInfo, error) {
+	if spec.Name == "" {
+		return nil, fmt.Errorf("chat name is required")
+	}
+
+	userIds, unresolved, err := s.resolveMemberUserIds(spec.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	body := larkim.NewCreateChatReqBodyBuilder().
+		Name(spec.Name).
+		Description(spec.Description).
+		ChatMode("group").
+		ChatType("private")
+	if spec.ChatType != "" {
+		body.ChatType(spec.ChatType)
+	}
+
+	if spec.Owner != nil {
+		ownerIds, ownerUnresolved, err := s.resolveMemberUserIds([]*UserIdentifier{spec.Owner})
+		if err != nil {
+			return nil, err
+		}
+		if len(ownerUnresolved) > 0 {
+			return nil, &UnresolvedRecipientError{ReceiveIdType: spec.Owner.IdType, ReceiveId: spec.Owner.Id}
+		}
+		body.OwnerId(ownerIds[0])
+	}
+
+	initial := userIds
+	var rest []string
+	if len(initial) > chatMemberBatchSize {
+		initial, rest = userIds[:chatMemberBatchSize], userIds[chatMemberBatchSize:]
+	}
+	if len(initial) > 0 {
+		body.UserIdList(initial)
+	}
+
+	builder := larkim.NewCreateChatReqBuilder().
+		UserIdType("user_id").
+		Body(body.Build())
+	if spec.Uuid != "" {
+		builder.Uuid(spec.Uuid)
+	}
+
+	resp, err := s.client.Im.Chat.Create(context.Background(), builder.Build())
+	if err != nil {
+		return nil, fmt.Errorf("create chat request failed: %v", err)
+	}
+	if !resp.Success() {
+		return nil, fmt.Errorf("create chat failed: %w", &FeishuError{Code: resp.Code, Msg: resp.Msg})
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("create chat failed: empty response")
+	}
+
+	info := &ChatInfo{
+		ChatId:            getStringValue(resp.Data.ChatId),
+		Name:              getStringValue(resp.Data.Name),
+		Description:       getStringValue(resp.Data.Description),
+		OwnerId:           getStringValue(resp.Data.OwnerId),
+		ChatMembersResult: &ChatMembersResult{Unresolved: unresolved},
+	}
+
+	if len(rest) > 0 {
+		result, err := s.addChatMemberIds(info.ChatId, rest)
+		if err != nil {
+			// 群已创建，返回错误会让调用方重试时重复建群，在结果中返回失败的成员
+			log.Printf("Failed to add members to chat %s: %v", info.ChatId, err)
+			info.MembersError = err.Error()
+			info.NotAddedUserIds = rest
+			return info, nil
+		}
+		info.InvalidIdList = result.InvalidIdList
+		info.NotExistedIdList = result.NotExistedIdList
+		info.PendingApprovalIdList = result.PendingApprovalIdList
+	}
+
+	return info, nil
+}
+
+// AddChatMembers 拉用户入群
+func (s *FeishuService) AddChatMembers(chatId string, members []*UserIdentifier) (*ChatMembersResult, error) {
+	userIds, unresolved, err := s.resolveMemberUserIds(members)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ChatMembersResult{}
+	if len(userIds) > 0 {
+		result, err = s.addChatMemberIds(chatId, userIds)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result.Unresolved = unresolved
+	return result, nil
+}
+
+// addChatMemberIds 按 user_id 分批拉用户入群，部分用户无效时其余用户仍然入群
+func (s *FeishuService) addChatMemberIds(chatId string, userIds []string) (*ChatMembersResult, error) {
+	result := &ChatMembersResult{}
+	pending := append([]string{}, userIds...)
+	for len(pending) > 0 {
+		chunk := takeChunk(&pending, chatMemberBatchSize)
+
+		req := larkim.NewCreateChatMembersReqBuilder().
+			ChatId(chatId).
+			MemberIdType("user_id").
+			SucceedType(1).
+			Body(larkim.NewCreateChatMembersReqBodyBuilder().
+				IdList(chunk).
+				Build()).
+			Build()
+
+		resp, err := s.client.Im.ChatMembers.Create(context.Background(), req)
+		if err != nil {
+			return nil, fmt.Errorf("add chat members request failed: %v", err)
+		}
+		if !resp.Success() {
+			return nil, fmt.Errorf("add chat members failed: %w", &FeishuError{Code: resp.Code, Msg: resp.Msg})
+		}
+		if resp.Data != nil {
+			result.InvalidIdList = append(result.InvalidIdList, resp.Data.InvalidIdList...)
+			result.NotExistedIdList = append(result.NotExistedIdList, resp.Data.NotExistedIdList...)
+			result.PendingApprovalIdList = append(result.PendingApprovalIdList, resp.Data.PendingApprovalIdList...)
+		}
+	}
+	return result, nil
+}
+
+// RemoveChatMembers 将用户移出群
+func (s *FeishuService) RemoveChatMembers(chatId string, members []*UserIdentifier) (*ChatMembersResult, error) {
+	userIds, unresolved, err := s.resolveMemberUserIds(members)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ChatMembersResult{Unresolved: unresolved}
+	for len(userIds) > 0 {
+		chunk := takeChunk(&userIds, chatMemberBatchSize)
+
+		req := larkim.NewDeleteChatMembersReqBuilder().
+			ChatId(chatId).
+			MemberIdType("user_id").
+			Body(larkim.NewDeleteChatMembersReqBodyBuilder().
+				IdList(chunk).
+				Build()).
+			Build()
+
+		resp, err := s.client.Im.ChatMembers.Delete(context.Background(), req)
+		if err != nil {
+			return nil, fmt.Errorf("remove chat members request failed: %v", err)
+		}
+		if !resp.Success() {
+			return nil, fmt.Errorf("remove chat members failed: %w", &FeishuError{Code: resp.Code, Msg: resp.Msg})
+		}
+		if resp.Data != nil {
+			result.InvalidIdList = append(result.InvalidIdList, resp.Data.InvalidIdList...)
+		}
+	}
+	return result, nil
+}
+
+// ListChats 分页获取机器人所在的群列表
+func (s *FeishuService) ListChats(pageToken string, pageSize int) (*larkim.ListChatRespData, error) {
+	builder := larkim.NewListChatReqBuilder().
+		UserIdType("user_id").
+		SortType("ByCreateTimeAsc")
+	if pageToken != "" {
+		builder.PageToken(pageToken)
+	}
+	if pageSize > 0 {
+		builder.PageSize(pageSize)
+	}
+
+	resp, err := s.client.Im.Chat.List(context.Background(), builder.Build())
+	if err != nil {
+		return nil, fmt.Errorf("list chats request failed: %v", err)
+	}
+	if !resp.Success() {
+		return nil, fmt.Errorf("list chats failed: %w", &FeishuError{Code: resp.Code, Msg: resp.Msg})
+	}
+	return resp.Data, nil
+}
+
+// GetChat 获取群信息
+func (s *FeishuService) GetChat(chatId string) (*larkim.GetChatRespData, error) {
+	req := larkim.NewGetChatReqBuilder().
+		ChatId(chatId).
+		UserIdType("user_id").
+		Build()
+
+	resp, err := s.client.Im.Chat.Get(context.Background(), req)
+	if err != nil {
+		return nil, fmt.Errorf("get chat request failed: %v", err)
+	}
+	if !resp.Success() {
+		return nil, fmt.Errorf("get chat failed: %w", &FeishuError{Code: resp.Code, Msg: resp.Msg})
+	}
+	return resp.Data, nil
+}
+
+// DisbandChat 解散群，机器人需要是群主或创建者
+func (s *FeishuService) DisbandChat(chatId string) error {
+	req := larkim.NewDeleteChatReqBuilder().
+		ChatId(chatId).
+		Build()
+
+	resp, err := s.client.Im.Chat.Delete(context.Background(), req)
+	if err != nil {
+		return fmt.Errorf("disband chat request failed: %v", err)
+	}
+	if !resp.Success() {
+		return fmt.Errorf("disband chat failed: %w", &FeishuError{Code: resp.Code, Msg: resp.Msg})
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// 命令名只允许字母、数字、下划线和中划线，如 /deploy、/on-call
+var commandNamePattern = regexp.MustCompile(`^/[A-Za-z0-9_-]+$`)
+
+// 群聊中 @ 用户在消息文本中的占位符，如 @_user_1
+var mentionPattern = regexp.MustCompile(`@_user_\d+`)
+
+// Command 机器人命令
+type Command struct {
+	Name        string // 以 / 开头，如 /deploy
+	Description string // 在 /help 中展示的说明
+	Usage       string // 参数说明，如 "<服务名> [版本]"
+
+	// 权限控制，均为空时所有人可用；AllowedUsers 可以填写 user_id 或 open_id
+	AllowedUsers []string
+	AllowedChats []string
+	// 自定义权限检查，设置后与 AllowedUsers、AllowedChats 同时生效
+	Permission func(ctx *CommandContext) bool
+
+	Handler func(ctx *CommandContext) error
+}
+
+// CommandContext 命令的执行上下文
+type CommandContext struct {
+	Command   string   // 命令名，如 /deploy
+	Args      []string // 按空格拆分的参数，支持双引号包含空格
+	RawArgs   string   // 命令名之后的原始文本
+	MessageId string
+	ChatId    string
+	ChatType  string    // p2p、group 或 topic_group
+	Sender    *UserInfo // 发送者，通讯录缓存中存在时包含姓名等信息
+
+	service *FeishuService
+}
+
+// Reply 以文本消息回复命令
+func (c *CommandContext) Reply(text string) error {
+	_, err := c.service.SendTextMessage("", "", text, c.replyOptions()...)
+	return err
+}
+
+// ReplyCard 以消息卡片回复命令
+func (c *CommandContext) ReplyCard(card *Card) error {
+	_, err := c.service.SendCardMessage("", "", card, c.replyOptions()...)
+	return err
+}
+
+func (c *CommandContext) replyOptions() []SendOption {
+	return []SendOption{WithReplyTo(c.MessageId, false), WithCaller("command:" + c.Command)}
+}
+
+// commandRouter 按命令名分发消息
+type commandRouter struct {
+	mu       sync.RWMutex
+	commands map[string]*Command
+}
+
+func newCommandRouter() *commandRouter {
+	return &commandRouter{commands: make(map[string]*Command)}
+}
+
+// RegisterCommand 注册机器人命令，命令名不区分大小写
+func (s *FeishuService) RegisterCommand(cmd *Command) error {
+	if cmd == nil || cmd.Handler == nil {
+		return fmt.Errorf("command handler is required")
+	}
+	if !commandNamePattern.MatchString(cmd.Name) {
+		return fmt.Errorf("invalid command name: %q", cmd.Name)
+	}
+
+	name := strings.ToLower(cmd.Name)
+	s.commands.mu.Lock()
+	defer s.commands.mu.Unlock()
+	if _, ok := s.commands.commands[name]; ok {
+		return fmt.Errorf("command %s is already registered", name)
+	}
+	s.commands.commands[name] = cmd
+	return nil
+}
+
+// Commands 返回已注册的命令，按命令名排序
+func (s *FeishuService) Commands() []*Command {
+	s.commands.mu.RLock()
+	defer s.commands.mu.RUnlock()
+
+	commands := make([]*Command, 0, len(s.commands.commands))
+	for _, cmd := range s.commands.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands
+}
+
+// registerBuiltinCommands 注册内置命令
+func (s *FeishuService) registerBuiltinCommands() {
+	err := s.RegisterCommand(&Command{
+		Name:        "/help",
+		Description: "查看可用命令",
+		Usage:       "[命令名]",
+		Handler:     s.helpCommand,
+	})
+	if err != nil {
+		log.Printf("Failed to register /help command: %v", err)
+	}
+}
+
+// helpCommand 列出发送者有权限使用的命令，带参数时展示该命令的用法
+func (s *FeishuService) helpCommand(ctx *CommandContext) error {
+	if len(ctx.Args) > 0 {
+		name := strings.ToLower(ctx.Args[0])
+		if !strings.HasPrefix(name, "/") {
+			name = "/" + name
+		}
+		cmd := s.lookupCommand(name)
+		if cmd == nil || !commandAllowed(cmd, ctx) {
+			return ctx.Reply(fmt.Sprintf("未知命令 %s，发送 /help 查看可用命令", name))
+		}
+		return ctx.ReplyCard(NewCardBuilder().
+			Header(cmd.Name, CardTemplateBlue).
+			Markdown(fmt.Sprintf("%s\n\n**用法**：`%s`", defaultString(cmd.Description, "-"), commandUsage(cmd))).
+			Build())
+	}
+
+	var lines []string
+	for _, cmd := range s.Commands() {
+		if commandAllowed(cmd, ctx) {
+			lines = append(lines, fmt.Sprintf("**%s** %s", commandUsage(cmd), cmd.Description))
+		}
+	}
+	return ctx.ReplyCard(NewCardBuilder().
+		Header("可用命令", CardTemplateBlue).
+		Markdown(strings.Join(lines, "\n")).
+		Note("发送 /help <命令名> 查看命令用法").
+		Build())
+}
+
+func (s *FeishuService) lookupCommand(name string) *Command {
+	s.commands.mu.RLock()
+	defer s.commands.mu.RUnlock()
+	return s.commands.commands[name]
+}
+
+// commandUsage 命令名与参数说明，如 "/deploy <服务名>"
+func commandUsage(cmd *Command) string {
+	if cmd.Usage == "" {
+		return cmd.Name
+	}
+	return cmd.Name + " " + cmd.Usage
+}
+
+// commandAllowed 检查发送者是否有权限执行命令
+func commandAllowed(cmd *Command, ctx *CommandContext) bool {
+	if len(cmd.AllowedUsers) > 0 {
+		allowed := false
+		for _, id := range cmd.AllowedUsers {
+			if id != "" && (id == ctx.Sender.UserID || id == ctx.Sender.OpenID) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	if len(cmd.AllowedChats) > 0 {
+		allowed := false
+		for _, id := range cmd.AllowedChats {
+			if id == ctx.ChatId {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	if cmd.Permission != nil && !cmd.Permission(ctx) {
+		return false
+	}
+	return true
+}
+
+// handleCommandMessage 解析接收到的文本消息，以 / 开头时执行对应命令
+func (s *FeishuService) handleCommandMessage(_ context.Context, event *larkim.P2MessageReceiveV1) error {
+	if event.Event == nil || event.Event.Message == nil {
+		return nil
+	}
+	message := event.Event.Message
+	if getStringValue(message.MessageType) != "text" {
+		return nil
+	}
+
+	var content struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(getStringValue(message.Content)), &content); err != nil {
+		return fmt.Errorf("parse message content failed: %v", err)
+	}
+	name, rawArgs, ok := parseCommand(content.Text)
+	if !ok {
+		return nil
+	}
+
+	ctx := &CommandContext{
+		Command:   name,
+		Args:      splitCommandArgs(rawArgs),
+		RawArgs:   rawArgs,
+		MessageId: getStringValue(message.MessageId),
+		ChatId:    getStringValue(message.ChatId),
+		ChatType:  getStringValue(message.ChatType),
+		Sender:    s.commandSender(event.Event.Sender),
+		service:   s,
+	}
+
+	cmd := s.lookupCommand(name)
+	if cmd == nil {
+		// 机器人可以读取群内所有消息时，群聊中未 @ 机器人的未知命令不回复
+		if ctx.ChatType != "p2p" && len(message.Mentions) == 0 {
+			return nil
+		}
+		return ctx.Reply(fmt.Sprintf("未知命令 %s，发送 /help 查看可用命令", name))
+	}
+	if !commandAllowed(cmd, ctx) {
+		return ctx.Reply(fmt.Sprintf("你没有执行 %s 的权限", name))
+	}
+
+	if err := cmd.Handler(ctx); err != nil {
+		log.Printf("Command %s failed: %v", name, err)
+		return ctx.Reply(fmt.Sprintf("%s 执行失败: %v", name, err))
+	}
+	return nil
+}
+
+// commandSender 获取命令发送者信息
+func (s *FeishuService) commandSender(sender *larkim.EventSender) *UserInfo {
+	if sender == nil || sender.SenderId == nil {
+		return &UserInfo{}
+	}
+	return s.eventOperator(getStringValue(sender.SenderId.UserId), getStringValue(sender.SenderId.OpenId), getStringValue(sender.SenderId.UnionId))
+}
+
+// parseCommand 去除 @ 占位符后解析命令名与参数，如 "@_user_1 /deploy api v2" 为 "/deploy" 与 "api v2"
+func parseCommand(text string) (name, rawArgs string, ok bool) {
+	text = strings.TrimSpace(mentionPattern.ReplaceAllString(text, ""))
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+
+	name = text
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		name, rawArgs = text[:i], text[i:]
+	}
+	name = strings.ToLower(name)
+	if !commandNamePattern.MatchString(name) {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(rawArgs), true
+}
+
+// splitCommandArgs 按空白拆分参数，双引号内的空白不拆分
+func splitCommandArgs(s string) []string {
+	var args []string
+	var current strings.Builder
+	inQuotes, hasArg := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case unicode.IsSpace(r) && !inQuotes:
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteRune(r)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+	return args
+}
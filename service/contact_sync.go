@@ -0,0 +1,515 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	larkcontact "github.com/larksuite/oapi-sdk-go/v3/service/contact/v3"
+)
+
+// 通讯录接口单页最大条数
+const contactPageSize = 50
+
+// ErrContactSyncRunning 已有同步任务在运行
+var ErrContactSyncRunning = errors.New("contact sync is already running")
+
+// ContactSyncStatus 通讯录同步状态
+type ContactSyncStatus struct {
+	Running        bool       `json:"running"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	Departments    int        `json:"departments"`
+	Users          int        `json:"users"`
+}
+
+// contactSyncState 同步任务的运行状态，保证同一时间只有一个同步任务
+type contactSyncState struct {
+	mu     sync.Mutex
+	status ContactSyncStatus
+}
+
+// contactSnapshot 一次同步拉取到的部门与用户
+type contactSnapshot struct {
+	departments []*larkcontact.Department
+	users       []*larkcontact.User // 同一用户属于多个部门时只保留一份
+}
+
+// StartContactSync 启动通讯录定时全量同步，启动时立即同步一次，ctx 取消时退出
+// 两次全量同步之间的用户变化由通讯录事件增量更新，未配置同步间隔时不定时同步
+func (s *FeishuService) StartContactSync(ctx context.Context) {
+	interval := s.config.ContactSyncInterval
+	if s.db == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.SyncContacts(); err != nil {
+				log.Printf("Contact sync failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Printf("Contact sync started, interval %v", interval)
+}
+
+// ContactSyncStatus 返回通讯录同步状态
+func (s *FeishuService) ContactSyncStatus() ContactSyncStatus {
+	s.contactSync.mu.Lock()
+	defer s.contactSync.mu.Unlock()
+	return s.contactSync.status
+}
+
+// SyncContacts 递归遍历整个部门树，将部门与用户全量写入本地缓存，并删除已不存在的部门与用户
+func (s *FeishuService) SyncContacts() (*ContactSyncStatus, error) {
+	startedAt, err := s.beginContactSync()
+	if err != nil {
+		return nil, err
+	}
+	return s.runContactSync(startedAt)
+}
+
+// TriggerContactSync 在后台启动一次通讯录同步，已有同步任务在运行时返回 ErrContactSyncRunning
+func (s *FeishuService) TriggerContactSync() error {
+	startedAt, err := s.beginContactSync()
+	if err != nil {
+		return err
+	}
+	go func() {
+		if _, err := s.runContactSync(startedAt); err != nil {
+			log.Printf("Contact sync failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// beginContactSync 标记同步任务开始
+func (s *FeishuService) beginContactSync() (time.Time, error) {
+	if s.db == nil {
+		return time.Time{}, fmt.Errorf("contact cache is not configured")
+	}
+
+	s.contactSync.mu.Lock()
+	defer s.contactSync.mu.Unlock()
+	if s.contactSync.status.Running {
+		return time.Time{}, ErrContactSyncRunning
+	}
+	startedAt := time.Now()
+	s.contactSync.status.Running = true
+	s.contactSync.status.LastStartedAt = &startedAt
+	return startedAt, nil
+}
+
+// runContactSync 执行同步并记录结果
+func (s *FeishuService) runContactSync(startedAt time.Time) (*ContactSyncStatus, error) {
+	snapshot, err := s.fetchContacts(s.config.ContactRootDepartmentId, -1)
+	if err == nil {
+		err = s.saveContacts(snapshot, startedAt)
+	}
+
+	s.contactSync.mu.Lock()
+	defer s.contactSync.mu.Unlock()
+	finishedAt := time.Now()
+	s.contactSync.status.Running = false
+	s.contactSync.status.LastFinishedAt = &finishedAt
+	if err != nil {
+		s.contactSync.status.LastError = err.Error()
+		return nil, err
+	}
+	s.contactSync.status.LastError = ""
+	s.contactSync.status.Departments = len(snapshot.departments)
+	s.contactSync.status.Users = len(snapshot.users)
+	log.Printf("Contact sync finished: %d departments, %d users in %v",
+		len(snapshot.departments), len(snapshot.users), finishedAt.Sub(startedAt))
+
+	status := s.contactSync.status
+	return &status, nil
+}
+
+// fetchContacts 从根部门开始逐层拉取子部门及其直属用户
+// maxDepth 为向下遍历的最大层数，0 表示只获取根部门，小于 0 表示不限层数
+func (s *FeishuService) fetchContacts(rootDepartmentId string, maxDepth int) (*contactSnapshot, error) {
+	type queued struct {
+		id    string
+		depth int
+	}
+
+	snapshot := &contactSnapshot{}
+	seenUsers := make(map[string]bool)
+	visited := map[string]bool{rootDepartmentId: true}
+	queue := []queued{{id: rootDepartmentId}}
+
+	for len(queue) > 0 {
+		dept := queue[0]
+		queue = queue[1:]
+
+		users, err := s.listDepartmentUsers(dept.id)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			openId := getStringValue(user.OpenId)
+			if openId == "" || seenUsers[openId] {
+				continue
+			}
+			seenUsers[openId] = true
+			snapshot.users = append(snapshot.users, user)
+		}
+
+		if maxDepth >= 0 && dept.depth >= maxDepth {
+			continue
+		}
+
+		children, err := s.listChildDepartments(dept.id)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			childId := getStringValue(child.OpenDepartmentId)
+			if childId == "" || visited[childId] {
+				continue
+			}
+			visited[childId] = true
+			snapshot.departments = append(snapshot.departments, child)
+			queue = append(queue, queued{id: childId, depth: dept.depth + 1})
+		}
+	}
+
+	return snapshot, nil
+}
+
+// listChildDepartments 分页获取部门的直属子部门
+func (s *FeishuService) listChildDepartments(departmentId string) ([]*larkcontact.Department, error) {
+	var departments []*larkcontact.Department
+	pageToken := ""
+	for {
+		builder := larkcontact.NewChildrenDepartmentReqBuilder().
+			DepartmentId(departmentId).
+			DepartmentIdType("open_department_id").
+			UserIdType("user_id").
+			FetchChild(false).
+			PageSize(contactPageSize)
+		if pageToken != "" {
+			builder.PageToken(pageToken)
+		}
+
+		resp, err := s.client.Contact.Department.Children(context.Background(), builder.Build())
+		if err != nil {
+			return nil, fmt.Errorf("list child departments request failed: %v", err)
+		}
+		if !resp.Success() {
+			return nil, fmt.Errorf("list child departments of %s failed: %w", departmentId, &FeishuError{Code: resp.Code, Msg: resp.Msg})
+		}
+		if resp.Data == nil {
+			break
+		}
+
+		departments = append(departments, resp.Data.Items...)
+		if !getBoolValue(resp.Data.HasMore) || getStringValue(resp.Data.PageToken) == "" {
+			break
+		}
+		pageToken = getStringValue(resp.Data.PageToken)
+	}
+	return departments, nil
+}
+
+// listDepartmentUsers 分页获取部门的直属用户
+func (s *FeishuService) listDepartmentUsers(departmentId string) ([]*larkcontact.User, error) {
+	var users []*larkcontact.User
+	pageToken := ""
+	for {
+		builder := larkcontact.NewFindByDepartmentUserReqBuilder().
+			DepartmentId(departmentId).
+			DepartmentIdType("open_department_id").
+			UserIdType("user_id").
+			PageSize(contactPageSize)
+		if pageToken != "" {
+			builder.PageToken(pageToken)
+		}
+
+		resp, err := s.client.Contact.User.FindByDepartment(context.Background(), builder.Build())
+		if err != nil {
+			return nil, fmt.Errorf("list department users request failed: %v", err)
+		}
+		if !resp.Success() {
+			return nil, fmt.Errorf("list users of department %s failed: %w", departmentId, &FeishuError{Code: resp.Code, Msg: resp.Msg})
+		}
+		if resp.Data == nil {
+			break
+		}
+
+		users = append(users, resp.Data.Items...)
+		if !getBoolValue(resp.Data.HasMore) || getStringValue(resp.Data.PageToken) == "" {
+			break
+		}
+		pageToken = getStringValue(resp.Data.PageToken)
+	}
+	return users, nil
+}
+
+// saveContacts 在一个事务中写入同步结果，并删除本次同步未出现的旧数据
+func (s *FeishuService) saveContacts(snapshot *contactSnapshot, syncedAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, dept := range snapshot.departments {
+		memberCount := 0
+		if dept.MemberCount != nil {
+			memberCount = *dept.MemberCount
+		}
+		_, err := tx.Exec(`
+			INSERT OR REPLACE INTO contact_departments
+			(open_department_id, department_id, parent_department_id, name, leader_user_id, chat_id, member_count, synced_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, getStringValue(dept.OpenDepartmentId), getStringValue(dept.DepartmentId), getStringValue(dept.ParentDepartmentId),
+			getStringValue(dept.Name), getStringValue(dept.LeaderUserId), getStringValue(dept.ChatId), memberCount, syncedAt)
+		if err != nil {
+			return fmt.Errorf("save department failed: %v", err)
+		}
+	}
+
+	// 搜索索引随每次同步整体重建
+	if _, err := tx.Exec(`DELETE FROM contact_user_index`); err != nil {
+		return err
+	}
+
+	for _, user := range snapshot.users {
+		if err := saveContactUser(tx, user, syncedAt); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM contact_departments WHERE synced_at < ?`, syncedAt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM contact_users WHERE synced_at < ?`, syncedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// saveContactUser 写入单个用户及其搜索索引
+func saveContactUser(tx *sql.Tx, user *larkcontact.User, syncedAt time.Time) error {
+	if err := indexContactUser(tx, user); err != nil {
+		return fmt.Errorf("index user failed: %v", err)
+	}
+	departmentIds, _ := json.Marshal(user.DepartmentIds)
+	status := user.Status
+	if status == nil {
+		status = &larkcontact.UserStatus{}
+	}
+	_, err := tx.Exec(`
+		INSERT OR REPLACE INTO contact_users
+		(open_id, user_id, union_id, name, en_name, mobile, email, department_ids,
+			is_frozen, is_resigned, is_activated, is_exited, is_unjoin, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, getStringValue(user.OpenId), getStringValue(user.UserId), getStringValue(user.UnionId), getStringValue(user.Name),
+		getStringValue(user.EnName), getStringValue(user.Mobile), getStringValue(user.Email), string(departmentIds),
+		getBoolValue(status.IsFrozen), getBoolValue(status.IsResigned), getBoolValue(status.IsActivated),
+		getBoolValue(status.IsExited), getBoolValue(status.IsUnjoin), syncedAt)
+	if err != nil {
+		return fmt.Errorf("save user failed: %v", err)
+	}
+	return nil
+}
+
+// registerContactCacheHandlers 注册通讯录用户变更事件的处理函数，增量更新本地缓存
+func (s *FeishuService) registerContactCacheHandlers() {
+	s.OnUserCreated(func(ctx context.Context, event *larkcontact.P2UserCreatedV3) error {
+		if event.Event == nil {
+			return nil
+		}
+		return s.upsertCachedUser(event.Event.Object)
+	})
+	s.OnUserUpdated(func(ctx context.Context, event *larkcontact.P2UserUpdatedV3) error {
+		if event.Event == nil {
+			return nil
+		}
+		return s.upsertCachedUser(event.Event.Object)
+	})
+	s.OnUserDeleted(func(ctx context.Context, event *larkcontact.P2UserDeletedV3) error {
+		if event.Event == nil || event.Event.Object == nil {
+			return nil
+		}
+		return s.deleteCachedUser(getStringValue(event.Event.Object.OpenId))
+	})
+}
+
+// upsertCachedUser 将事件中的用户信息写入通讯录缓存，不在同步范围内的部门的用户不写入
+func (s *FeishuService) upsertCachedUser(user *larkcontact.UserEvent) error {
+	if s.db == nil || user == nil || getStringValue(user.OpenId) == "" {
+		return nil
+	}
+	if !s.inContactSyncScope(user.DepartmentIds) {
+		return s.deleteCachedUser(getStringValue(user.OpenId))
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = saveContactUser(tx, &larkcontact.User{
+		OpenId:        user.OpenId,
+		UnionId:       user.UnionId,
+		UserId:        user.UserId,
+		Name:          user.Name,
+		EnName:        user.EnName,
+		Mobile:        user.Mobile,
+		Email:         user.Email,
+		Status:        user.Status,
+		DepartmentIds: user.DepartmentIds,
+	}, time.Now())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteCachedUser 从通讯录缓存中删除用户
+func (s *FeishuService) deleteCachedUser(openId string) error {
+	if s.db == nil || openId == "" {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM contact_user_index WHERE open_id = ?`, openId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM contact_users WHERE open_id = ?`, openId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// inContactSyncScope 用户是否属于同步范围内的部门，从根部门同步时所有用户都在范围内
+func (s *FeishuService) inContactSyncScope(departmentIds []string) bool {
+	root := s.config.ContactRootDepartmentId
+	if root == "" || root == "0" {
+		return true
+	}
+
+	var count int
+	for _, id := range departmentIds {
+		if id == root {
+			return true
+		}
+		s.db.QueryRow(`SELECT COUNT(*) FROM contact_departments WHERE open_department_id = ?`, id).Scan(&count)
+		if count > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// mobileVariants 返回手机号可能的存储形式，通讯录中的中国大陆手机号带 +86 前缀
+func mobileVariants(mobile string) []string {
+	mobile = strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(mobile))
+	switch {
+	case strings.HasPrefix(mobile, "+86"):
+		return []string{mobile, strings.TrimPrefix(mobile, "+86")}
+	case strings.HasPrefix(mobile, "+"):
+		return []string{mobile}
+	default:
+		return []string{mobile, "+86" + mobile}
+	}
+}
+
+// cachedUserColumns 查询通讯录缓存用户时的字段，与 scanCachedUsers 对应
+const cachedUserColumns = `
+	u.user_id, u.open_id, u.union_id, u.mobile, u.email, u.name, u.department_ids,
+	u.is_frozen, u.is_resigned, u.is_activated, u.is_exited, u.is_unjoin
+`
+
+// findCachedUsers 从通讯录缓存中查找用户，按手机号或邮箱查找时只返回在职用户
+func (s *FeishuService) findCachedUsers(receiveIdType, receiveId string) ([]*UserInfo, error) {
+	if s.db == nil {
+		return nil, nil
+	}
+
+	var rows *sql.Rows
+	var err error
+	query := `SELECT ` + cachedUserColumns + ` FROM contact_users u`
+	switch receiveIdType {
+	case ReceiveIdTypeMobile:
+		variants := mobileVariants(receiveId)
+		if len(variants) == 1 {
+			variants = append(variants, variants[0])
+		}
+		rows, err = s.db.Query(query+` WHERE u.mobile IN (?, ?) AND u.is_resigned = 0`, variants[0], variants[1])
+	case ReceiveIdTypeEmail:
+		rows, err = s.db.Query(query+` WHERE u.email = ? COLLATE NOCASE AND u.is_resigned = 0`, strings.TrimSpace(receiveId))
+	case "user_id", "open_id", "union_id":
+		rows, err = s.db.Query(query+` WHERE u.`+receiveIdType+` = ?`, strings.TrimSpace(receiveId))
+	default:
+		return nil, fmt.Errorf("unsupported receive_id_type: %s", receiveIdType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCachedUsers(rows)
+}
+
+// scanCachedUsers 读取通讯录缓存的查询结果
+func scanCachedUsers(rows *sql.Rows) ([]*UserInfo, error) {
+	var users []*UserInfo
+	for rows.Next() {
+		user := &UserInfo{}
+		var userId, unionId, mobile, email, name, departmentIds sql.NullString
+		if err := rows.Scan(&userId, &user.OpenID, &unionId, &mobile, &email, &name, &departmentIds,
+			&user.Status.IsFrozen, &user.Status.IsResigned, &user.Status.IsActivated,
+			&user.Status.IsExited, &user.Status.IsUnjoin); err != nil {
+			return nil, err
+		}
+		user.UserID = userId.String
+		user.UnionID = unionId.String
+		user.Mobile = mobile.String
+		user.Email = email.String
+		user.Name = name.String
+		if departmentIds.String != "" {
+			json.Unmarshal([]byte(departmentIds.String), &user.DepartmentIds)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// cachedUserId 从通讯录缓存中获取手机号或邮箱对应的 user_id，未找到时返回空字符串
+func (s *FeishuService) cachedUserId(receiveIdType, receiveId string) string {
+	users, err := s.findCachedUsers(receiveIdType, receiveId)
+	if err != nil {
+		log.Printf("Failed to query contact cache: %v", err)
+		return ""
+	}
+	for _, user := range users {
+		if user.UserID != "" {
+			return user.UserID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	larkcontact "github.com/larksuite/oapi-sdk-go/v3/service/contact/v3"
+)
+
+// DepartmentUsers 部门成员列表
+type DepartmentUsers struct {
+	DepartmentId string      `json:"department_id"`
+	Departments  int         `json:"departments"` // 遍历到的子部门数量，不含起始部门
+	Users        []*UserInfo `json:"users"`
+}
+
+// ListDepartmentUsers 获取部门成员，自动翻页
+// recursive 为 true 时遍历整个子部门树，maxDepth 大于 0 时限制向下遍历的层数；
+// 属于多个部门的用户只返回一次
+func (s *FeishuService) ListDepartmentUsers(departmentId string, recursive bool, maxDepth int) (*DepartmentUsers, error) {
+	depth := 0
+	if recursive {
+		depth = -1
+		if maxDepth > 0 {
+			depth = maxDepth
+		}
+	}
+
+	snapshot, err := s.fetchContacts(departmentId, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DepartmentUsers{
+		DepartmentId: departmentId,
+		Departments:  len(snapshot.departments),
+		Users:        make([]*UserInfo, 0, len(snapshot.users)),
+	}
+	for _, user := range snapshot.users {
+		result.Users = append(result.Users, newUserInfo(user))
+	}
+	return result, nil
+}
+
+// newUserInfo 将通讯录用户转换为 UserInfo
+func newUserInfo(user *larkcontact.User) *UserInfo {
+	info := &UserInfo{
+		UserID:        getStringValue(user.UserId),
+		OpenID:        getStringValue(user.OpenId),
+		UnionID:       getStringValue(user.UnionId),
+		Mobile:        getStringValue(user.Mobile),
+		Email:         getStringValue(user.Email),
+		Name:          getStringValue(user.Name),
+		DepartmentIds: user.DepartmentIds,
+	}
+	if user.Status != nil {
+		info.Status = UserStatus{
+			IsFrozen:    getBoolValue(user.Status.IsFrozen),
+			IsResigned:  getBoolValue(user.Status.IsResigned),
+			IsActivated: getBoolValue(user.Status.IsActivated),
+			IsExited:    getBoolValue(user.Status.IsExited),
+			IsUnjoin:    getBoolValue(user.Status.IsUnjoin),
+		}
+	}
+	return info
+}
@@ -0,0 +1,13 @@
+package service
+
+import "fmt"
+
+// FeishuError 飞书开放平台接口返回的业务错误
+type FeishuError struct {
+	Code int
+	Msg  string
+}
+
+func (e *FeishuError) Error() string {
+	return fmt.Sprintf("code=%d, msg=%s", e.Code, e.Msg)
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkevent "github.com/larksuite/oapi-sdk-go/v3/event"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher"
+	larkcontact "github.com/larksuite/oapi-sdk-go/v3/service/contact/v3"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// 飞书未收到成功响应时会重推事件，最长间隔数小时，在此时长内按事件ID去重
+const eventDedupTTL = 8 * time.Hour
+
+// eventHandlers 已注册的事件处理函数
+type eventHandlers struct {
+	mu             sync.RWMutex
+	messageReceive []func(ctx context.Context, event *larkim.P2MessageReceiveV1) error
+	botAdded       []func(ctx context.Context, event *larkim.P2ChatMemberBotAddedV1) error
+	userCreated    []func(ctx context.Context, event *larkcontact.P2UserCreatedV3) error
+	userUpdated    []func(ctx context.Context, event *larkcontact.P2UserUpdatedV3) error
+	userDeleted    []func(ctx context.Context, event *larkcontact.P2UserDeletedV3) error
+
+	seenMu    sync.Mutex
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+func newEventHandlers() *eventHandlers {
+	return &eventHandlers{seen: make(map[string]time.Time)}
+}
+
+// firstSeen 记录事件ID，事件已处理过时返回 false
+func (h *eventHandlers) firstSeen(eventId string) bool {
+	h.seenMu.Lock()
+	defer h.seenMu.Unlock()
+
+	now := time.Now()
+	if now.Sub(h.lastPrune) > time.Minute {
+		for id, expiresAt := range h.seen {
+			if now.After(expiresAt) {
+				delete(h.seen, id)
+			}
+		}
+		h.lastPrune = now
+	}
+
+	if expiresAt, ok := h.seen[eventId]; ok && now.Before(expiresAt) {
+		return false
+	}
+	h.seen[eventId] = now.Add(eventDedupTTL)
+	return true
+}
+
+// OnMessageReceive 注册接收消息事件（im.message.receive_v1）的处理函数
+func (s *FeishuService) OnMessageReceive(handler func(ctx context.Context, event *larkim.P2MessageReceiveV1) error) {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+	s.events.messageReceive = append(s.events.messageReceive, handler)
+}
+
+// OnBotAddedToChat 注册机器人进群事件（im.chat.member.bot.added_v1）的处理函数
+func (s *FeishuService) OnBotAddedToChat(handler func(ctx context.Context, event *larkim.P2ChatMemberBotAddedV1) error) {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+	s.events.botAdded = append(s.events.botAdded, handler)
+}
+
+// OnUserCreated 注册员工入职事件（contact.user.created_v3）的处理函数
+func (s *FeishuService) OnUserCreated(handler func(ctx context.Context, event *larkcontact.P2UserCreatedV3) error) {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+	s.events.userCreated = append(s.events.userCreated, handler)
+}
+
+// OnUserUpdated 注册员工信息变化事件（contact.user.updated_v3）的处理函数
+func (s *FeishuService) OnUserUpdated(handler func(ctx context.Context, event *larkcontact.P2UserUpdatedV3) error) {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+	s.events.userUpdated = append(s.events.userUpdated, handler)
+}
+
+// OnUserDeleted 注册员工离职事件（contact.user.deleted_v3）的处理函数
+func (s *FeishuService) OnUserDeleted(handler func(ctx context.Context, event *larkcontact.P2UserDeletedV3) error) {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+	s.events.userDeleted = append(s.events.userDeleted, handler)
+}
+
+// EventSubscriptionEnabled 是否配置了事件订阅的 Verification Token
+func (s *FeishuService) EventSubscriptionEnabled() bool {
+	return s.config.EventVerificationToken != ""
+}
+
+// EventDispatcher 返回事件分发器，负责 URL 校验、验签、解密并将事件分发给已注册的处理函数
+func (s *FeishuService) EventDispatcher() *dispatcher.EventDispatcher {
+	return s.eventDispatcher
+}
+
+// newEventDispatcher 创建事件分发器，处理函数可以在创建之后注册
+func (s *FeishuService) newEventDispatcher() *dispatcher.EventDispatcher {
+	eventDispatcher := dispatcher.NewEventDispatcher(s.config.EventVerificationToken, s.config.EventEncryptKey)
+	// 默认的 Debug 级别会把解密后的事件内容写入日志
+	eventDispatcher.InitConfig(larkevent.WithLogLevel(larkcore.LogLevelInfo))
+	return eventDispatcher.
+		OnP2MessageReceiveV1(func(ctx context.Context, event *larkim.P2MessageReceiveV1) error {
+			s.events.mu.RLock()
+			handlers := s.events.messageReceive
+			s.events.mu.RUnlock()
+			return dispatchEvent(ctx, s, event.EventV2Base, event, handlers)
+		}).
+		OnP2ChatMemberBotAddedV1(func(ctx context.Context, event *larkim.P2ChatMemberBotAddedV1) error {
+			s.events.mu.RLock()
+			handlers := s.events.botAdded
+			s.events.mu.RUnlock()
+			return dispatchEvent(ctx, s, event.EventV2Base, event, handlers)
+		}).
+		OnP2UserCreatedV3(func(ctx context.Context, event *larkcontact.P2UserCreatedV3) error {
+			s.events.mu.RLock()
+			handlers := s.events.userCreated
+			s.events.mu.RUnlock()
+			return dispatchEvent(ctx, s, event.EventV2Base, event, handlers)
+		}).
+		OnP2UserUpdatedV3(func(ctx context.Context, event *larkcontact.P2UserUpdatedV3) error {
+			s.events.mu.RLock()
+			handlers := s.events.userUpdated
+			s.events.mu.RUnlock()
+			return dispatchEvent(ctx, s, event.EventV2Base, event, handlers)
+		}).
+		OnP2UserDeletedV3(func(ctx context.Context, event *larkcontact.P2UserDeletedV3) error {
+			s.events.mu.RLock()
+			handlers := s.events.userDeleted
+			s.events.mu.RUnlock()
+			return dispatchEvent(ctx, s, event.EventV2Base, event, handlers)
+		}).
+		OnP2CardActionTrigger(s.onCardActionTrigger)
+}
+
+// verifyEventToken 校验回调请求中的 Verification Token
+// 未配置 Encrypt Key 时飞书不对请求签名，只能依靠该 Token 校验来源；长连接已通过应用凭证鉴权，无需校验
+func (s *FeishuService) verifyEventToken(ctx context.Context, token string) bool {
+	return fromLongConnection(ctx) || token == s.config.EventVerificationToken
+}
+
+// eventOperator 事件中的用户信息，优先从通讯录缓存中补充姓名等信息
+func (s *FeishuService) eventOperator(userId, openId, unionId string) *UserInfo {
+	if openId != "" {
+		if users, err := s.findCachedUsers("open_id", openId); err != nil {
+			log.Printf("Failed to query contact cache: %v", err)
+		} else if len(users) > 0 {
+			return users[0]
+		}
+	}
+	return &UserInfo{UserID: userId, OpenID: openId, UnionID: unionId}
+}
+
+// dispatchEvent 校验事件 Token 并去重后异步执行处理函数，飞书要求 3 秒内响应事件推送
+func dispatchEvent[T any](ctx context.Context, s *FeishuService, base *larkevent.EventV2Base, event T, handlers []func(context.Context, T) error) error {
+	if base == nil || base.Header == nil {
+		return fmt.Errorf("event header is missing")
+	}
+	header := base.Header
+	if !s.verifyEventToken(ctx, header.Token) {
+		return fmt.Errorf("event token mismatch")
+	}
+	if !s.events.firstSeen(header.EventID) {
+		log.Printf("Skip duplicated event %s (%s)", header.EventID, header.EventType)
+		return nil
+	}
+
+	for _, handler := range handlers {
+		go func(handler func(context.Context, T) error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Event handler of %s panicked: %v", header.EventType, r)
+				}
+			}()
+			if err := handler(context.Background(), event); err != nil {
+				log.Printf("Failed to handle event %s (%s): %v", header.EventID, header.EventType, err)
+			}
+		}(handler)
+	}
+	return nil
+}
@@ -2,12 +2,18 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"oapi-sdk-go-demo/config"
+	"sync"
+	"time"
 
 	lark "github.com/larksuite/oapi-sdk-go/v3"
+	larkcard "github.com/larksuite/oapi-sdk-go/v3/card"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher"
 	larkcontact "github.com/larksuite/oapi-sdk-go/v3/service/contact/v3"
 	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
 )
@@ -15,6 +21,20 @@ import (
 type FeishuService struct {
 	client *lark.Client
 	config *config.Config
+	db     *sql.DB
+
+	outboxNotify chan struct{}
+	recipients   *recipientCache
+	contactSync  contactSyncState
+
+	events          *eventHandlers
+	eventDispatcher *dispatcher.EventDispatcher
+	commands        *commandRouter
+
+	cardActions       *cardActionRouter
+	cardActionHandler *larkcard.CardActionHandler
+
+	alertCardsMu sync.Mutex
 }
 
 // 用户信息结构体
@@ -38,12 +58,28 @@ type UserStatus struct {
 	IsUnjoin    bool `json:"is_unjoin"`
 }
 
-func NewFeishuService(cfg *config.Config) *FeishuService {
+func NewFeishuService(cfg *config.Config, db *sql.DB) *FeishuService {
 	client := lark.NewClient(cfg.AppID, cfg.AppSecret)
-	return &FeishuService{
+	s := &FeishuService{
 		client: client,
 		config: cfg,
+		db:     db,
+
+		outboxNotify: make(chan struct{}, 1),
+		recipients:   newRecipientCache(),
+		events:       newEventHandlers(),
+		commands:     newCommandRouter(),
+		cardActions:  newCardActionRouter(),
 	}
+	s.eventDispatcher = s.newEventDispatcher()
+	s.cardActionHandler = s.newCardActionHandler()
+
+	// 接收到的以 / 开头的文本消息作为机器人命令处理
+	s.registerBuiltinCommands()
+	s.OnMessageReceive(s.handleCommandMessage)
+	// 通讯录变更事件实时更新本地缓存，两次全量同步之间的变化无需等待下次同步
+	s.registerContactCacheHandlers()
+	return s
 }
 
 // BatchGetUserIds 根据手机号或邮箱批量获取用户ID信息
@@ -76,6 +112,13 @@ func (s *FeishuService) SearchUserByPhone(phoneNumber string) ([]*UserInfo, erro
 		return nil, fmt.Errorf("phone number is required")
 	}
 
+	// 优先查询本地通讯录缓存，未命中时再调用通讯录接口
+	if users, err := s.findCachedUsers(ReceiveIdTypeMobile, phoneNumber); err != nil {
+		log.Printf("Failed to query contact cache: %v", err)
+	} else if len(users) > 0 {
+		return users, nil
+	}
+
 	data, err := s.BatchGetUserIds([]string{phoneNumber}, []string{}, false)
 	if err != nil {
 		return nil, err
@@ -93,7 +136,7 @@ func (s *FeishuService) SearchUserByPhone(phoneNumber string) ([]*UserInfo, erro
 		}
 	}
 
-	return users, nil
+	return s.fillUserProfiles(users), nil
 }
 
 // UploadImage 上传图片到飞书并返回image_key
@@ -141,47 +184,125 @@ func (s *FeishuService) UploadFile(fileType, fileName string, file io.Reader, du
 }
 
 // SendTextMessage 发送文本消息
-func (s *FeishuService) SendTextMessage(receiveIdType, receiveId, content string) (*larkim.CreateMessageRespData, error) {
+func (s *FeishuService) SendTextMessage(receiveIdType, receiveId, content string, opts ...SendOption) (*larkim.CreateMessageRespData, error) {
 	// 构建消息内容
 	msgContent := map[string]interface{}{
 		"text": content,
 	}
-	contentBytes, _ := json.Marshal(msgContent)
 
-	req := larkim.NewCreateMessageReqBuilder().
-		ReceiveIdType(receiveIdType).
-		Body(larkim.NewCreateMessageReqBodyBuilder().
-			ReceiveId(receiveId).
-			MsgType("text").
-			Content(string(contentBytes)).
-			Build()).
-		Build()
+	data, err := s.createMessage(receiveIdType, receiveId, "text", msgContent, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("send message failed: %w", err)
+	}
+	return data, nil
+}
 
-	resp, err := s.client.Im.Message.Create(context.Background(), req)
+// SendImageMessage 发送图片消息
+func (s *FeishuService) SendImageMessage(receiveIdType, receiveId, imageKey string, opts ...SendOption) (*larkim.CreateMessageRespData, error) {
+	msgContent := map[string]interface{}{
+		"image_key": imageKey,
+	}
+
+	data, err := s.createMessage(receiveIdType, receiveId, "image", msgContent, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("send image message failed: %w", err)
+	}
+	return data, nil
+}
+
+// SendFileMessage 发送文件消息
+func (s *FeishuService) SendFileMessage(receiveIdType, receiveId, fileKey string, opts ...SendOption) (*larkim.CreateMessageRespData, error) {
+	msgContent := map[string]interface{}{
+		"file_key": fileKey,
+	}
+
+	data, err := s.createMessage(receiveIdType, receiveId, "file", msgContent, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("send file message failed: %w", err)
+	}
+	return data, nil
+}
+
+// ReplyTextMessage 回复指定消息，replyInThread 为 true 时以话题形式回复
+func (s *FeishuService) ReplyTextMessage(messageId, content string, replyInThread bool) (*larkim.CreateMessageRespData, error) {
+	return s.SendTextMessage("", "", content, WithReplyTo(messageId, replyInThread))
+}
+
+// createMessage 序列化消息内容并发送，设置了异步选项时写入发件箱由后台投递
+func (s *FeishuService) createMessage(receiveIdType, receiveId, msgType string, msgContent interface{}, opts ...SendOption) (*larkim.CreateMessageRespData, error) {
+	contentBytes, err := json.Marshal(msgContent)
 	if err != nil {
 		return nil, err
 	}
 
-	if !resp.Success() {
-		return nil, fmt.Errorf("send message failed: code=%d, msg=%s", resp.Code, resp.Msg)
+	options := applySendOptions(opts)
+	if options.Uuid != "" {
+		if data := s.cachedResponse(options.Uuid); data != nil {
+			return data, nil
+		}
 	}
 
-	return resp.Data, nil
+	// 手机号、邮箱类型的接收者先解析为 user_id
+	if options.ReplyToMessageId == "" {
+		receiveIdType, receiveId, err = s.resolveReceiveId(receiveIdType, receiveId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.AsyncId != "" {
+		if err := s.enqueueMessage(options.AsyncId, receiveIdType, receiveId, msgType, string(contentBytes), options); err != nil {
+			return nil, err
+		}
+		return &larkim.CreateMessageRespData{}, nil
+	}
+
+	return s.dispatchMessage(receiveIdType, receiveId, msgType, string(contentBytes), options)
 }
 
-// SendImageMessage 发送图片消息
-func (s *FeishuService) SendImageMessage(receiveIdType, receiveId, imageKey string) (*larkim.CreateMessageRespData, error) {
-	msgContent := map[string]interface{}{
-		"image_key": imageKey,
+// dispatchMessage 调用发送消息接口，设置了回复消息ID时调用回复消息接口，并记录发送日志
+func (s *FeishuService) dispatchMessage(receiveIdType, receiveId, msgType, content string, options *SendOptions) (*larkim.CreateMessageRespData, error) {
+	start := time.Now()
+
+	var data *larkim.CreateMessageRespData
+	var err error
+	if options.ReplyToMessageId != "" {
+		data, err = s.replyMessage(options.ReplyToMessageId, msgType, content, options.ReplyInThread, options.Uuid)
+	} else {
+		data, err = s.sendMessage(receiveIdType, receiveId, msgType, content, options.Uuid)
+	}
+
+	s.logMessage(&messageLogEntry{
+		receiveIdType:    receiveIdType,
+		receiveId:        receiveId,
+		replyToMessageId: options.ReplyToMessageId,
+		msgType:          msgType,
+		content:          content,
+		caller:           options.Caller,
+		latency:          time.Since(start),
+		data:             data,
+		err:              err,
+	})
+	if err != nil {
+		return nil, err
 	}
-	contentBytes, _ := json.Marshal(msgContent)
 
+	s.recordSentMessage(data, msgType, receiveIdType, receiveId)
+	if options.Uuid != "" {
+		s.cacheResponse(options.Uuid, data)
+	}
+	return data, nil
+}
+
+// sendMessage 调用发送消息接口
+func (s *FeishuService) sendMessage(receiveIdType, receiveId, msgType, content, uuid string) (*larkim.CreateMessageRespData, error) {
 	req := larkim.NewCreateMessageReqBuilder().
 		ReceiveIdType(receiveIdType).
 		Body(larkim.NewCreateMessageReqBodyBuilder().
 			ReceiveId(receiveId).
-			MsgType("image").
-			Content(string(contentBytes)).
+			MsgType(msgType).
+			Content(content).
+			Uuid(uuid).
 			Build()).
 		Build()
 
@@ -191,38 +312,35 @@ func (s *FeishuService) SendImageMessage(receiveIdType, receiveId, imageKey stri
 	}
 
 	if !resp.Success() {
-		return nil, fmt.Errorf("send image message failed: code=%d, msg=%s", resp.Code, resp.Msg)
+		return nil, &FeishuError{Code: resp.Code, Msg: resp.Msg}
 	}
 
 	return resp.Data, nil
 }
 
-// SendFileMessage 发送文件消息
-func (s *FeishuService) SendFileMessage(receiveIdType, receiveId, fileKey string) (*larkim.CreateMessageRespData, error) {
-	msgContent := map[string]interface{}{
-		"file_key": fileKey,
-	}
-	contentBytes, _ := json.Marshal(msgContent)
-
-	req := larkim.NewCreateMessageReqBuilder().
-		ReceiveIdType(receiveIdType).
-		Body(larkim.NewCreateMessageReqBodyBuilder().
-			ReceiveId(receiveId).
-			MsgType("file").
-			Content(string(contentBytes)).
+// replyMessage 调用回复消息接口
+func (s *FeishuService) replyMessage(messageId, msgType, content string, replyInThread bool, uuid string) (*larkim.CreateMessageRespData, error) {
+	req := larkim.NewReplyMessageReqBuilder().
+		MessageId(messageId).
+		Body(larkim.NewReplyMessageReqBodyBuilder().
+			MsgType(msgType).
+			Content(content).
+			ReplyInThread(replyInThread).
+			Uuid(uuid).
 			Build()).
 		Build()
 
-	resp, err := s.client.Im.Message.Create(context.Background(), req)
+	resp, err := s.client.Im.Message.Reply(context.Background(), req)
 	if err != nil {
 		return nil, err
 	}
 
 	if !resp.Success() {
-		return nil, fmt.Errorf("send file message failed: code=%d, msg=%s", resp.Code, resp.Msg)
+		return nil, &FeishuError{Code: resp.Code, Msg: resp.Msg}
 	}
 
-	return resp.Data, nil
+	// 回复与发送接口的返回结构一致
+	return (*larkim.CreateMessageRespData)(resp.Data), nil
 }
 
 // 辅助函数定义
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// 幂等键的本地缓存有效期
+const idempotencyTTL = "-24 hours"
+
+// cachedResponse 返回幂等键对应的首次发送结果，未命中时返回 nil
+func (s *FeishuService) cachedResponse(uuid string) *larkim.CreateMessageRespData {
+	data := &larkim.CreateMessageRespData{}
+	if !s.loadCachedResponse(uuid, data) {
+		return nil
+	}
+	return data
+}
+
+// cacheResponse 保存幂等键对应的发送结果，并清理过期的记录
+func (s *FeishuService) cacheResponse(uuid string, data *larkim.CreateMessageRespData) {
+	if data == nil {
+		return
+	}
+	s.storeCachedResponse(uuid, getStringValue(data.MessageId), data)
+}
+
+// loadCachedResponse 读取幂等键对应的首次结果到 v 中，未命中时返回 false
+func (s *FeishuService) loadCachedResponse(uuid string, v interface{}) bool {
+	if s.db == nil {
+		return false
+	}
+
+	var response string
+	err := s.db.QueryRow(`
+		SELECT response FROM idempotency_keys
+		WHERE idempotency_key = ? AND created_at >= datetime('now', ?)
+	`, uuid, idempotencyTTL).Scan(&response)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal([]byte(response), v) == nil
+}
+
+// storeCachedResponse 保存幂等键对应的结果，并清理过期的记录
+func (s *FeishuService) storeCachedResponse(uuid, messageId string, v interface{}) {
+	if s.db == nil {
+		return
+	}
+
+	response, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	_, err = s.db.Exec(`
+		INSERT OR REPLACE INTO idempotency_keys (idempotency_key, message_id, response)
+		VALUES (?, ?, ?)
+	`, uuid, messageId, string(response))
+	if err != nil {
+		log.Printf("Failed to cache idempotency key %s: %v", uuid, err)
+	}
+
+	if _, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE created_at < datetime('now', ?)`, idempotencyTTL); err != nil {
+		log.Printf("Failed to purge idempotency keys: %v", err)
+	}
+}
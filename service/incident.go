@@ -0,0 +1,245 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"oapi-sdk-go-demo/composite_api/im"
+)
+
+// 作战群创建中的记录超过该时长视为进程异常中断，允许重新创建
+const warRoomStaleAfter = 10 * time.Minute
+
+// 作战群创建后保存记录的最大尝试次数
+const warRoomSaveAttempts = 3
+
+var (
+	// ErrWarRoomInProgress 同一事故的作战群正在创建中
+	ErrWarRoomInProgress = errors.New("war room for this incident is being created")
+	// ErrWarRoomNotSaved 作战群已创建但保存记录失败，事故ID已释放，重复请求会创建新的作战群
+	ErrWarRoomNotSaved = errors.New("war room created but not saved")
+)
+
+// IncidentSpec 创建事故作战群的参数
+type IncidentSpec struct {
+	IncidentId  string   `json:"incident_id"`
+	Title       string   `json:"title"`
+	Severity    string   `json:"severity"` // 如 P0、P1、P2
+	Summary     string   `json:"summary"`
+	OwnerUserId string   `json:"owner_user_id"`
+	UserIds     []string `json:"user_ids"`
+	Mobiles     []string `json:"mobiles"`
+	Emails      []string `json:"emails"`
+}
+
+// WarRoom 事故作战群
+type WarRoom struct {
+	IncidentId        string   `json:"incident_id"`
+	ChatId            string   `json:"chat_id"`
+	CardMessageId     string   `json:"card_message_id"`
+	RunbookMessageId  string   `json:"runbook_message_id,omitempty"`
+	ShareLink         string   `json:"share_link"`
+	Existing          bool     `json:"existing"` // 作战群已存在，本次请求未重复创建
+	UnresolvedMobiles []string `json:"unresolved_mobiles,omitempty"`
+	UnresolvedEmails  []string `json:"unresolved_emails,omitempty"`
+}
+
+// CreateIncidentWarRoom 为事故创建作战群，同一事故ID只创建一次，重复请求返回已创建的作战群
+// runbook 不为 nil 时以 runbookName 为文件名发送到群中；任意一步失败时解散已创建的群
+// 群已创建但保存记录失败时释放事故ID，同时返回作战群与 ErrWarRoomNotSaved，由调用方根据 chat_id 处理已创建的群
+func (s *FeishuService) CreateIncidentWarRoom(spec *IncidentSpec, runbookName string, runbook io.Reader) (*WarRoom, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("war room store is not configured")
+	}
+	if spec.IncidentId == "" {
+		return nil, fmt.Errorf("incident id is required")
+	}
+
+	existing, err := s.claimWarRoom(spec.IncidentId)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	card, err := json.Marshal(buildIncidentCard(spec))
+	if err != nil {
+		s.releaseWarRoom(spec.IncidentId)
+		return nil, err
+	}
+
+	request := &im.CreateWarRoomRequest{
+		ChatName:        incidentChatName(spec),
+		ChatDescription: spec.Summary,
+		OwnerUserId:     spec.OwnerUserId,
+		UserIds:         spec.UserIds,
+		Mobiles:         spec.Mobiles,
+		Emails:          spec.Emails,
+		Card:            string(card),
+	}
+	if runbook != nil {
+		request.RunbookFileName = runbookName
+		request.RunbookFile = runbook
+	}
+
+	resp, err := im.CreateWarRoom(s.client, request)
+	if err != nil {
+		s.releaseWarRoom(spec.IncidentId)
+		return nil, fmt.Errorf("create war room failed: %w", err)
+	}
+
+	room := &WarRoom{
+		IncidentId:        spec.IncidentId,
+		ChatId:            resp.ChatId,
+		CardMessageId:     resp.CardMessageId,
+		RunbookMessageId:  resp.RunbookMessageId,
+		ShareLink:         resp.ShareLink,
+		UnresolvedMobiles: resp.UnresolvedMobiles,
+		UnresolvedEmails:  resp.UnresolvedEmails,
+	}
+
+	// 记录事故卡片，之后可以通过卡片更新接口更新事故状态
+	s.recordSentMessage(resp.CardMessageResponse, "interactive", "chat_id", room.ChatId)
+
+	if err := s.saveWarRoom(room); err != nil {
+		log.Printf("Failed to save war room %s of incident %s: %v", room.ChatId, spec.IncidentId, err)
+		// 不保留创建中的记录，否则重复请求的结果取决于记录是否已过期
+		s.releaseWarRoom(spec.IncidentId)
+		return room, fmt.Errorf("%w: %v", ErrWarRoomNotSaved, err)
+	}
+	return room, nil
+}
+
+// saveWarRoom 将作战群标记为已创建，写入失败时重试
+func (s *FeishuService) saveWarRoom(room *WarRoom) error {
+	var err error
+	for attempt := 1; attempt <= warRoomSaveAttempts; attempt++ {
+		_, err = s.db.Exec(`
+			UPDATE incident_war_rooms
+			SET status = 'ready', chat_id = ?, card_message_id = ?, runbook_message_id = ?, share_link = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE incident_id = ?
+		`, room.ChatId, room.CardMessageId, room.RunbookMessageId, room.ShareLink, room.IncidentId)
+		if err == nil {
+			return nil
+		}
+		if attempt < warRoomSaveAttempts {
+			time.Sleep(time.Duration(attempt) * 200 * time.Millisecond)
+		}
+	}
+	return err
+}
+
+// GetIncidentWarRoom 获取已创建的事故作战群，不存在时返回 nil
+func (s *FeishuService) GetIncidentWarRoom(incidentId string) (*WarRoom, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("war room store is not configured")
+	}
+
+	room := &WarRoom{IncidentId: incidentId, Existing: true}
+	var chatId, cardMessageId, runbookMessageId, shareLink sql.NullString
+	err := s.db.QueryRow(`
+		SELECT chat_id, card_message_id, runbook_message_id, share_link
+		FROM incident_war_rooms WHERE incident_id = ? AND status = 'ready'
+	`, incidentId).Scan(&chatId, &cardMessageId, &runbookMessageId, &shareLink)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	room.ChatId = chatId.String
+	room.CardMessageId = cardMessageId.String
+	room.RunbookMessageId = runbookMessageId.String
+	room.ShareLink = shareLink.String
+	return room, nil
+}
+
+// claimWarRoom 占用事故ID，作战群已存在时返回已有的作战群
+func (s *FeishuService) claimWarRoom(incidentId string) (*WarRoom, error) {
+	result, err := s.db.Exec(`INSERT OR IGNORE INTO incident_war_rooms (incident_id, status) VALUES (?, 'creating')`, incidentId)
+	if err != nil {
+		return nil, err
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return nil, nil
+	}
+
+	room, err := s.GetIncidentWarRoom(incidentId)
+	if err != nil || room != nil {
+		return room, err
+	}
+
+	// 创建中的记录长时间未完成时重新占用
+	result, err = s.db.Exec(`
+		UPDATE incident_war_rooms SET updated_at = CURRENT_TIMESTAMP
+		WHERE incident_id = ? AND status = 'creating' AND updated_at <= datetime('now', ?)
+	`, incidentId, fmt.Sprintf("-%d seconds", int(warRoomStaleAfter.Seconds())))
+	if err != nil {
+		return nil, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrWarRoomInProgress
+	}
+	return nil, nil
+}
+
+// releaseWarRoom 创建失败时释放事故ID，允许重试
+func (s *FeishuService) releaseWarRoom(incidentId string) {
+	if _, err := s.db.Exec(`DELETE FROM incident_war_rooms WHERE incident_id = ? AND status = 'creating'`, incidentId); err != nil {
+		log.Printf("Failed to release war room of incident %s: %v", incidentId, err)
+	}
+}
+
+// incidentChatName 作战群名称，如 "[P1] INC-123 支付服务不可用"
+func incidentChatName(spec *IncidentSpec) string {
+	var parts []string
+	if spec.Severity != "" {
+		parts = append(parts, "["+spec.Severity+"]")
+	}
+	parts = append(parts, spec.IncidentId)
+	if spec.Title != "" {
+		parts = append(parts, spec.Title)
+	}
+	return strings.Join(parts, " ")
+}
+
+// incidentTemplate 按事故级别选择卡片颜色
+func incidentTemplate(severity string) string {
+	switch strings.ToUpper(severity) {
+	case "P0", "P1":
+		return CardTemplateRed
+	case "P2":
+		return CardTemplateOrange
+	default:
+		return CardTemplateYellow
+	}
+}
+
+// buildIncidentCard 生成置顶在作战群中的事故卡片
+func buildIncidentCard(spec *IncidentSpec) *Card {
+	builder := NewCardBuilder().
+		Header(incidentChatName(spec), incidentTemplate(spec.Severity)).
+		Fields(
+			NewCardField("**事故编号**\n"+spec.IncidentId, true),
+			NewCardField("**级别**\n"+defaultString(spec.Severity, "-"), true),
+			NewCardField("**状态**\n处理中", true),
+			NewCardField("**开始时间**\n"+time.Now().Format("2006-01-02 15:04:05"), true),
+		)
+	if spec.Summary != "" {
+		builder.Divider().Markdown(spec.Summary)
+	}
+	return builder.Build()
+}
+
+// defaultString 字符串为空时返回默认值
+func defaultString(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkws "github.com/larksuite/oapi-sdk-go/v3/ws"
+)
+
+// 事件接收方式
+const (
+	EventModeWebhook   = "webhook"
+	EventModeWebSocket = "websocket"
+)
+
+// longConnectionKey 标记事件来自长连接
+type longConnectionKey struct{}
+
+func fromLongConnection(ctx context.Context) bool {
+	v, _ := ctx.Value(longConnectionKey{}).(bool)
+	return v
+}
+
+// StartEventLongConnection 事件接收方式为 websocket 时建立长连接接收事件，与回调地址共用同一个事件分发器
+func (s *FeishuService) StartEventLongConnection(ctx context.Context) {
+	if s.config.EventMode != EventModeWebSocket {
+		return
+	}
+
+	opts := []larkws.ClientOption{
+		larkws.WithEventHandler(s.eventDispatcher),
+		larkws.WithLogLevel(larkcore.LogLevelInfo),
+	}
+	if s.config.EventWebSocketDomain != "" {
+		opts = append(opts, larkws.WithDomain(s.config.EventWebSocketDomain))
+	}
+	client := larkws.NewClient(s.config.AppID, s.config.AppSecret, opts...)
+
+	go func() {
+		// 连接断开后自动重连，只有应用凭证错误等无法重试的错误才会返回
+		if err := client.Start(context.WithValue(ctx, longConnectionKey{}, true)); err != nil {
+			log.Printf("Event long connection stopped: %v", err)
+		}
+	}()
+	log.Printf("Event long connection started")
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"oapi-sdk-go-demo/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	larkws "github.com/larksuite/oapi-sdk-go/v3/ws"
+)
+
+// fakeWSServer 模拟飞书长连接服务端：下发建连地址，并按 SDK 的帧协议推送事件
+type fakeWSServer struct {
+	*httptest.Server
+	conns     chan *websocket.Conn
+	responses chan *larkws.Frame
+}
+
+func newFakeWSServer(t *testing.T) *fakeWSServer {
+	t.Helper()
+	server := &fakeWSServer{
+		conns:     make(chan *websocket.Conn, 1),
+		responses: make(chan *larkws.Frame, 16),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(larkws.GenEndpointUri, func(w http.ResponseWriter, r *http.Request) {
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?device_id=test&service_id=1"
+		json.NewEncoder(w).Encode(&larkws.EndpointResp{
+			Code: larkws.OK,
+			Data: &larkws.Endpoint{
+				Url:          url,
+				ClientConfig: &larkws.ClientConfig{PingInterval: 120},
+			},
+		})
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade websocket failed: %v", err)
+			return
+		}
+		server.conns <- conn
+		// 收集客户端对数据帧的响应，忽略心跳
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var frame larkws.Frame
+			if err := frame.Unmarshal(msg); err == nil && larkws.FrameType(frame.Method) == larkws.FrameTypeData {
+				server.responses <- &frame
+			}
+		}
+	})
+
+	server.Server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// accept 等待客户端建立长连接
+func (s *fakeWSServer) accept(t *testing.T) *websocket.Conn {
+	t.Helper()
+	select {
+	case conn := <-s.conns:
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not connect")
+		return nil
+	}
+}
+
+// sendEvent 以单个数据帧推送事件
+func (s *fakeWSServer) sendEvent(t *testing.T, conn *websocket.Conn, messageId string, event []byte) {
+	t.Helper()
+	frame := &larkws.Frame{
+		Service: 1,
+		Method:  int32(larkws.FrameTypeData),
+		Headers: []larkws.Header{
+			{Key: larkws.HeaderType, Value: string(larkws.MessageTypeEvent)},
+			{Key: larkws.HeaderMessageID, Value: messageId},
+			{Key: larkws.HeaderSum, Value: "1"},
+			{Key: larkws.HeaderSeq, Value: "0"},
+			{Key: larkws.HeaderTraceID, Value: "trace-" + messageId},
+		},
+		Payload: event,
+	}
+	bs, err := frame.Marshal()
+	if err != nil {
+		t.Fatalf("marshal frame failed: %v", err)
+	}
+	if err := conn.WriteMessage(websocket.BinaryMessage, bs); err != nil {
+		t.Fatalf("write frame failed: %v", err)
+	}
+}
+
+// response 等待客户端对数据帧的响应
+func (s *fakeWSServer) response(t *testing.T) *larkws.Frame {
+	t.Helper()
+	select {
+	case frame := <-s.responses:
+		return frame
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not respond")
+		return nil
+	}
+}
+
+func TestEventLongConnectionDispatchesMessageReceive(t *testing.T) {
+	server := newFakeWSServer(t)
+	s := NewFeishuService(&config.Config{
+		AppID:                  "cli_test",
+		AppSecret:              "secret",
+		EventVerificationToken: "token", // 长连接推送的事件不携带 Token，不应被拒绝
+		EventMode:              EventModeWebSocket,
+		EventWebSocketDomain:   server.URL,
+	}, nil)
+
+	received := make(chan *larkim.P2MessageReceiveV1, 1)
+	s.OnMessageReceive(func(ctx context.Context, event *larkim.P2MessageReceiveV1) error {
+		received <- event
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.StartEventLongConnection(ctx)
+	conn := server.accept(t)
+
+	event := []byte(`{
+		"schema": "2.0",
+		"header": {
+			"event_id": "evt-1",
+			"event_type": "im.message.receive_v1",
+			"create_time": "1700000000000",
+			"app_id": "cli_test",
+			"tenant_key": "tenant"
+		},
+		"event": {
+			"sender": {"sender_id": {"open_id": "ou_sender"}, "sender_type": "user"},
+			"message": {
+				"message_id": "om_1",
+				"chat_id": "oc_1",
+				"chat_type": "group",
+				"message_type": "text",
+				"content": "{\"text\":\"hello\"}"
+			}
+		}
+	}`)
+	server.sendEvent(t, conn, "msg-1", event)
+
+	select {
+	case got := <-received:
+		message := got.Event.Message
+		if getStringValue(message.MessageId) != "om_1" || getStringValue(message.ChatId) != "oc_1" {
+			t.Errorf("message = %s/%s, want om_1/oc_1", getStringValue(message.MessageId), getStringValue(message.ChatId))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message receive handler was not called")
+	}
+
+	frame := server.response(t)
+	if id := larkws.Headers(frame.Headers).GetString(larkws.HeaderMessageID); id != "msg-1" {
+		t.Errorf("response message_id = %q, want msg-1", id)
+	}
+	var resp larkws.Response
+	if err := json.Unmarshal(frame.Payload, &resp); err != nil {
+		t.Fatalf("unmarshal response failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("response code = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+var (
+	// ErrMessageNotOwned 消息不是由本服务发送或已被撤回
+	ErrMessageNotOwned = errors.New("message was not sent by this service or has been recalled")
+	// ErrMessageNotEditable 消息类型不支持当前操作，如编辑卡片消息或更新非卡片消息
+	ErrMessageNotEditable = errors.New("message type does not support this operation")
+)
+
+// recordSentMessage 记录本服务发送成功的消息ID
+func (s *FeishuService) recordSentMessage(data *larkim.CreateMessageRespData, msgType, receiveIdType, receiveId string) {
+	if s.db == nil || data == nil || data.MessageId == nil {
+		return
+	}
+
+	_, err := s.db.Exec(`
+		INSERT OR IGNORE INTO sent_messages (message_id, msg_type, receive_id_type, receive_id, chat_id)
+		VALUES (?, ?, ?, ?, ?)
+	`, *data.MessageId, msgType, receiveIdType, receiveId, getStringValue(data.ChatId))
+	if err != nil {
+		log.Printf("Failed to record sent message %s: %v", *data.MessageId, err)
+	}
+}
+
+// sentMessageType 返回本服务发送的消息类型，未找到或已撤回时返回 ErrMessageNotOwned
+func (s *FeishuService) sentMessageType(messageId string) (string, error) {
+	if s.db == nil {
+		return "", fmt.Errorf("message store is not configured")
+	}
+
+	var msgType string
+	err := s.db.QueryRow(`
+		SELECT msg_type FROM sent_messages WHERE message_id = ? AND recalled = 0
+	`, messageId).Scan(&msgType)
+	if err == sql.ErrNoRows {
+		return "", ErrMessageNotOwned
+	}
+	if err != nil {
+		return "", err
+	}
+	return msgType, nil
+}
+
+// touchSentMessage 更新消息记录的状态
+func (s *FeishuService) touchSentMessage(messageId string, recalled bool) {
+	_, err := s.db.Exec(`
+		UPDATE sent_messages SET recalled = ?, updated_at = CURRENT_TIMESTAMP WHERE message_id = ?
+	`, recalled, messageId)
+	if err != nil {
+		log.Printf("Failed to update sent message %s: %v", messageId, err)
+	}
+}
+
+// EditTextMessage 编辑已发送的文本消息
+func (s *FeishuService) EditTextMessage(messageId, content string) error {
+	return s.editMessage(messageId, "text", map[string]interface{}{"text": content})
+}
+
+// EditPostMessage 编辑已发送的富文本消息
+func (s *FeishuService) EditPostMessage(messageId string, post PostMessage) error {
+	if err := post.Validate(); err != nil {
+		return err
+	}
+	return s.editMessage(messageId, "post", post)
+}
+
+// editMessage 调用编辑消息接口，仅支持 text 和 post 类型
+func (s *FeishuService) editMessage(messageId, msgType string, msgContent interface{}) error {
+	sentType, err := s.sentMessageType(messageId)
+	if err != nil {
+		return err
+	}
+	if sentType != "text" && sentType != "post" {
+		return fmt.Errorf("%w: message of type %s cannot be edited", ErrMessageNotEditable, sentType)
+	}
+
+	contentBytes, err := json.Marshal(msgContent)
+	if err != nil {
+		return err
+	}
+
+	req := larkim.NewUpdateMessageReqBuilder().
+		MessageId(messageId).
+		Body(larkim.NewUpdateMessageReqBodyBuilder().
+			MsgType(msgType).
+			Content(string(contentBytes)).
+			Build()).
+		Build()
+
+	resp, err := s.client.Im.Message.Update(context.Background(), req)
+	if err != nil {
+		return fmt.Errorf("edit message request failed: %v", err)
+	}
+
+	if !resp.Success() {
+		return fmt.Errorf("edit message failed: %w", &FeishuError{Code: resp.Code, Msg: resp.Msg})
+	}
+
+	s.touchSentMessage(messageId, false)
+	return nil
+}
+
+// UpdateCardMessage 更新已发送的消息卡片
+func (s *FeishuService) UpdateCardMessage(messageId string, card *Card) error {
+	if card == nil {
+		return fmt.Errorf("card is required")
+	}
+	return s.patchCard(messageId, card)
+}
+
+// UpdateRawCardMessage 使用原始 JSON 更新已发送的消息卡片
+func (s *FeishuService) UpdateRawCardMessage(messageId, cardJSON string) error {
+	if !json.Valid([]byte(cardJSON)) {
+		return fmt.Errorf("card is not valid json")
+	}
+	return s.patchCard(messageId, json.RawMessage(cardJSON))
+}
+
+// patchCard 调用更新卡片消息接口
+func (s *FeishuService) patchCard(messageId string, card interface{}) error {
+	sentType, err := s.sentMessageType(messageId)
+	if err != nil {
+		return err
+	}
+	if sentType != "interactive" {
+		return fmt.Errorf("%w: message of type %s is not a card", ErrMessageNotEditable, sentType)
+	}
+
+	contentBytes, err := json.Marshal(card)
+	if err != nil {
+		return err
+	}
+
+	req := larkim.NewPatchMessageReqBuilder().
+		MessageId(messageId).
+		Body(larkim.NewPatchMessageReqBodyBuilder().
+			Content(string(contentBytes)).
+			Build()).
+		Build()
+
+	resp, err := s.client.Im.Message.Patch(context.Background(), req)
+	if err != nil {
+		return fmt.Errorf("update card message request failed: %v", err)
+	}
+
+	if !resp.Success() {
+		return fmt.Errorf("update card message failed: %w", &FeishuError{Code: resp.Code, Msg: resp.Msg})
+	}
+
+	s.touchSentMessage(messageId, false)
+	return nil
+}
+
+// RecallMessage 撤回已发送的消息
+func (s *FeishuService) RecallMessage(messageId string) error {
+	if _, err := s.sentMessageType(messageId); err != nil {
+		return err
+	}
+
+	req := larkim.NewDeleteMessageReqBuilder().
+		MessageId(messageId).
+		Build()
+
+	resp, err := s.client.Im.Message.Delete(context.Background(), req)
+	if err != nil {
+		return fmt.Errorf("recall message request failed: %v", err)
+	}
+
+	if !resp.Success() {
+		return fmt.Errorf("recall message failed: %w", &FeishuError{Code: resp.Code, Msg: resp.Msg})
+	}
+
+	s.touchSentMessage(messageId, true)
+	return nil
+}
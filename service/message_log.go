@@ -0,0 +1,61 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// messageLogEntry 单次消息发送的日志
+type messageLogEntry struct {
+	receiveIdType    string
+	receiveId        string
+	replyToMessageId string
+	msgType          string
+	content          string
+	caller           string
+	latency          time.Duration
+	data             *larkim.CreateMessageRespData
+	err              error
+}
+
+// logMessage 将消息发送结果写入 message_log 表
+func (s *FeishuService) logMessage(entry *messageLogEntry) {
+	if s.db == nil {
+		return
+	}
+
+	hash := sha256.Sum256([]byte(entry.content))
+	status := "success"
+	errorCode := 0
+	errorMsg := ""
+	if entry.err != nil {
+		status = "failed"
+		errorMsg = entry.err.Error()
+		var feishuErr *FeishuError
+		if errors.As(entry.err, &feishuErr) {
+			errorCode = feishuErr.Code
+			errorMsg = feishuErr.Msg
+		}
+	}
+
+	messageId := ""
+	if entry.data != nil {
+		messageId = getStringValue(entry.data.MessageId)
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO message_log
+		(receive_id_type, receive_id, reply_to_message_id, msg_type, content_hash, message_id, status, error_code, error_msg, latency_ms, caller)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.receiveIdType, entry.receiveId, entry.replyToMessageId, entry.msgType,
+		hex.EncodeToString(hash[:]), messageId, status, errorCode, errorMsg,
+		entry.latency.Milliseconds(), entry.caller)
+	if err != nil {
+		log.Printf("Failed to write message log: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+// SendOptions 发送消息的可选参数
+type SendOptions struct {
+	ReplyToMessageId string // 被回复的消息ID，设置后调用回复消息接口
+	ReplyInThread    bool   // 是否以话题形式回复
+	Caller           string // 调用方标识，记录在消息发送日志中
+	AsyncId          string // 发件箱消息ID，设置后消息写入发件箱异步投递
+	Uuid             string // 幂等键，透传给飞书并用于本地去重
+}
+
+// SendOption 设置发送消息的可选参数
+type SendOption func(*SendOptions)
+
+// WithReplyTo 以回复指定消息的方式发送，inThread 为 true 时以话题形式回复
+func WithReplyTo(messageId string, inThread bool) SendOption {
+	return func(o *SendOptions) {
+		o.ReplyToMessageId = messageId
+		o.ReplyInThread = inThread
+	}
+}
+
+// WithCaller 设置调用方标识
+func WithCaller(caller string) SendOption {
+	return func(o *SendOptions) {
+		o.Caller = caller
+	}
+}
+
+// WithAsync 将消息写入发件箱由后台异步投递，id 为发件箱消息ID
+func WithAsync(id string) SendOption {
+	return func(o *SendOptions) {
+		o.AsyncId = id
+	}
+}
+
+// WithUuid 设置幂等键，相同幂等键的重复请求返回首次发送的结果
+func WithUuid(uuid string) SendOption {
+	return func(o *SendOptions) {
+		o.Uuid = uuid
+	}
+}
+
+// applySendOptions 合并可选参数
+func applySendOptions(opts []SendOption) *SendOptions {
+	o := &SendOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
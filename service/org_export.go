@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	larkcontact "github.com/larksuite/oapi-sdk-go/v3/service/contact/v3"
+)
+
+// 根部门的 open_department_id
+const rootDepartmentId = "0"
+
+// OrgDepartment 组织架构中的部门节点
+type OrgDepartment struct {
+	OpenDepartmentId string           `json:"open_department_id"`
+	Name             string           `json:"name"`
+	LeaderUserId     string           `json:"leader_user_id,omitempty"`
+	Path             string           `json:"path"`
+	Members          []string         `json:"members"` // 直属成员的 open_id
+	Children         []*OrgDepartment `json:"children,omitempty"`
+}
+
+// OrgRosterEntry 花名册中的一行
+type OrgRosterEntry struct {
+	Name           string `json:"name"`
+	UserId         string `json:"user_id"`
+	OpenId         string `json:"open_id"`
+	Email          string `json:"email"`
+	Mobile         string `json:"mobile"`
+	DepartmentPath string `json:"department_path"` // 属于多个部门时以 "; " 分隔
+	LeaderUserId   string `json:"leader_user_id"`
+	Leader         string `json:"leader"` // 直属上级姓名，上级不在导出范围内时为空
+}
+
+// OrgChart 部门子树的组织架构快照
+type OrgChart struct {
+	Root   *OrgDepartment    `json:"root"`
+	Roster []*OrgRosterEntry `json:"roster"`
+}
+
+// ExportOrgChart 遍历部门子树，生成部门层级与成员花名册
+func (s *FeishuService) ExportOrgChart(departmentId string) (*OrgChart, error) {
+	root, err := s.orgRootDepartment(departmentId)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.fetchContacts(departmentId, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按父部门组装部门树，子部门保持接口返回的顺序
+	nodes := map[string]*OrgDepartment{departmentId: root}
+	for _, dept := range snapshot.departments {
+		id := getStringValue(dept.OpenDepartmentId)
+		nodes[id] = &OrgDepartment{
+			OpenDepartmentId: id,
+			Name:             getStringValue(dept.Name),
+			LeaderUserId:     getStringValue(dept.LeaderUserId),
+			Members:          []string{},
+		}
+	}
+	for _, dept := range snapshot.departments {
+		node := nodes[getStringValue(dept.OpenDepartmentId)]
+		if parent, ok := nodes[getStringValue(dept.ParentDepartmentId)]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	setOrgPaths(root, "")
+
+	names := make(map[string]string, len(snapshot.users))
+	for _, user := range snapshot.users {
+		if userId := getStringValue(user.UserId); userId != "" {
+			names[userId] = getStringValue(user.Name)
+		}
+	}
+
+	chart := &OrgChart{Root: root, Roster: make([]*OrgRosterEntry, 0, len(snapshot.users))}
+	for _, user := range snapshot.users {
+		openId := getStringValue(user.OpenId)
+		var paths []string
+		for _, id := range orgUserDepartments(user, departmentId) {
+			if node, ok := nodes[id]; ok {
+				node.Members = append(node.Members, openId)
+				paths = append(paths, node.Path)
+			}
+		}
+
+		leaderUserId := getStringValue(user.LeaderUserId)
+		chart.Roster = append(chart.Roster, &OrgRosterEntry{
+			Name:           getStringValue(user.Name),
+			UserId:         getStringValue(user.UserId),
+			OpenId:         openId,
+			Email:          getStringValue(user.Email),
+			Mobile:         getStringValue(user.Mobile),
+			DepartmentPath: strings.Join(paths, "; "),
+			LeaderUserId:   leaderUserId,
+			Leader:         names[leaderUserId],
+		})
+	}
+
+	return chart, nil
+}
+
+// orgRootDepartment 获取起始部门信息，根部门没有部门详情
+func (s *FeishuService) orgRootDepartment(departmentId string) (*OrgDepartment, error) {
+	root := &OrgDepartment{OpenDepartmentId: departmentId, Name: departmentId, Members: []string{}}
+	if departmentId == rootDepartmentId {
+		root.Name = "全公司"
+		return root, nil
+	}
+
+	req := larkcontact.NewGetDepartmentReqBuilder().
+		DepartmentId(departmentId).
+		DepartmentIdType("open_department_id").
+		UserIdType("user_id").
+		Build()
+
+	resp, err := s.client.Contact.Department.Get(context.Background(), req)
+	if err != nil {
+		return nil, fmt.Errorf("get department request failed: %v", err)
+	}
+	if !resp.Success() {
+		return nil, fmt.Errorf("get department failed: %w", &FeishuError{Code: resp.Code, Msg: resp.Msg})
+	}
+	if resp.Data != nil && resp.Data.Department != nil {
+		root.Name = getStringValue(resp.Data.Department.Name)
+		root.LeaderUserId = getStringValue(resp.Data.Department.LeaderUserId)
+	}
+	return root, nil
+}
+
+// orgUserDepartments 返回用户所属部门，直属于根部门的用户没有部门ID
+func orgUserDepartments(user *larkcontact.User, rootId string) []string {
+	if len(user.DepartmentIds) == 0 {
+		return []string{rootId}
+	}
+	return user.DepartmentIds
+}
+
+// setOrgPaths 计算部门路径，如 "研发中心/后端组"
+func setOrgPaths(node *OrgDepartment, parentPath string) {
+	node.Path = node.Name
+	if parentPath != "" {
+		node.Path = parentPath + "/" + node.Name
+	}
+	for _, child := range node.Children {
+		setOrgPaths(child, node.Path)
+	}
+}
+
+// WriteCSV 以 CSV 格式输出花名册
+func (chart *OrgChart) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"name", "user_id", "open_id", "email", "mobile", "department_path", "leader_user_id", "leader"}); err != nil {
+		return err
+	}
+	for _, entry := range chart.Roster {
+		err := writer.Write([]string{entry.Name, entry.UserId, entry.OpenId, entry.Email, entry.Mobile,
+			entry.DepartmentPath, entry.LeaderUserId, entry.Leader})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteDOT 以 Graphviz DOT 格式输出部门层级，成员作为部门的叶子节点
+func (chart *OrgChart) WriteDOT(w io.Writer) error {
+	names := make(map[string]string, len(chart.Roster))
+	for _, entry := range chart.Roster {
+		names[entry.OpenId] = entry.Name
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph org {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [fontname=\"sans-serif\"];\n")
+
+	var walk func(node *OrgDepartment)
+	walk = func(node *OrgDepartment) {
+		fmt.Fprintf(&b, "\t%s [label=%s, shape=box];\n", dotQuote("dept:"+node.OpenDepartmentId), dotQuote(node.Name))
+		for _, openId := range node.Members {
+			fmt.Fprintf(&b, "\t%s [label=%s, shape=ellipse];\n", dotQuote("user:"+openId), dotQuote(names[openId]))
+			fmt.Fprintf(&b, "\t%s -> %s;\n", dotQuote("dept:"+node.OpenDepartmentId), dotQuote("user:"+openId))
+		}
+		for _, child := range node.Children {
+			fmt.Fprintf(&b, "\t%s -> %s;\n", dotQuote("dept:"+node.OpenDepartmentId), dotQuote("dept:"+child.OpenDepartmentId))
+			walk(child)
+		}
+	}
+	walk(chart.Root)
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote 转义为 DOT 的带引号字符串
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
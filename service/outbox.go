@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// 可重试的飞书错误码：频率限制与服务端内部错误
+var retryableCodes = map[int]bool{
+	99991400: true, // 应用请求频率超限
+	230020:   true, // 消息发送频率超限
+	11232:    true, // 消息发送频率超限（旧版接口）
+	11233:    true, // 消息发送频率超限（旧版接口）
+	90217:    true, // 请求过于频繁
+	2200:     true, // 服务内部错误
+	55001:    true, // 服务内部错误
+}
+
+const (
+	outboxBaseBackoff = 2 * time.Second
+	outboxMaxBackoff  = 10 * time.Minute
+	outboxPollPeriod  = time.Second
+)
+
+// outboxMessage 发件箱中的一条待投递消息
+type outboxMessage struct {
+	id               string
+	receiveIdType    string
+	receiveId        string
+	replyToMessageId string
+	replyInThread    bool
+	msgType          string
+	content          string
+	caller           string
+	uuid             string
+	attempts         int
+}
+
+// ErrDeadLetterNotFound 死信不存在
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// ErrDeadLetterRequeued 死信转入后，相同ID（幂等键）的消息已重新入队
+var ErrDeadLetterRequeued = errors.New("a message with the same id is already in the outbox")
+
+// NewOutboxId 生成发件箱消息ID
+func NewOutboxId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// IsRetryableError 判断发送失败是否可以重试，网络错误与频率限制、服务端错误可重试
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var feishuErr *FeishuError
+	if errors.As(err, &feishuErr) {
+		return retryableCodes[feishuErr.Code]
+	}
+	return true
+}
+
+// enqueueMessage 将消息写入发件箱
+func (s *FeishuService) enqueueMessage(id, receiveIdType, receiveId, msgType, content string, options *SendOptions) error {
+	if s.db == nil {
+		return fmt.Errorf("outbox is not configured")
+	}
+
+	// 相同ID（幂等键）的消息已在发件箱中时忽略
+	// 始终携带飞书幂等键，首次投递成功但响应丢失时重试不会重复发送，重放死信时沿用同一幂等键
+	_, err := s.db.Exec(`
+		INSERT OR IGNORE INTO message_outbox
+		(id, receive_id_type, receive_id, reply_to_message_id, reply_in_thread, msg_type, content, caller, uuid)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, id, receiveIdType, receiveId, options.ReplyToMessageId, options.ReplyInThread, msgType, content, options.Caller, outboxUuid(id, options.Uuid))
+	if err != nil {
+		return fmt.Errorf("enqueue message failed: %v", err)
+	}
+
+	s.notifyOutbox()
+	return nil
+}
+
+// outboxUuid 发件箱消息发送时使用的飞书幂等键，未指定时使用发件箱ID
+// 飞书幂等键最长 50 个字符，超长的ID取哈希
+func outboxUuid(id, uuid string) string {
+	if uuid != "" {
+		return uuid
+	}
+	if len(id) > 50 {
+		sum := sha256.Sum256([]byte(id))
+		return hex.EncodeToString(sum[:16])
+	}
+	return id
+}
+
+// notifyOutbox 唤醒空闲的投递协程
+func (s *FeishuService) notifyOutbox() {
+	select {
+	case s.outboxNotify <- struct{}{}:
+	default:
+	}
+}
+
+// StartOutbox 启动发件箱投递协程，ctx 取消时退出
+func (s *FeishuService) StartOutbox(ctx context.Context) {
+	if s.db == nil {
+		return
+	}
+
+	// 进程异常退出时遗留的处理中消息重新投递
+	if _, err := s.db.Exec(`UPDATE message_outbox SET status = 'pending' WHERE status = 'processing'`); err != nil {
+		log.Printf("Failed to reset outbox messages: %v", err)
+	}
+
+	workers := s.config.OutboxWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		go s.outboxWorker(ctx)
+	}
+	log.Printf("Outbox started with %d workers", workers)
+}
+
+// outboxWorker 循环领取并投递到期的消息
+func (s *FeishuService) outboxWorker(ctx context.Context) {
+	for {
+		msg, err := s.claimOutboxMessage()
+		if err != nil {
+			log.Printf("Failed to claim outbox message: %v", err)
+		}
+		if msg != nil {
+			s.deliverOutboxMessage(msg)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.outboxNotify:
+		case <-time.After(outboxPollPeriod):
+		}
+	}
+}
+
+// claimOutboxMessage 领取一条到期的消息并标记为处理中
+func (s *FeishuService) claimOutboxMessage() (*outboxMessage, error) {
+	msg := &outboxMessage{}
+	var receiveIdType, receiveId, replyTo, caller, uuid sql.NullString
+	err := s.db.QueryRow(`
+		UPDATE message_outbox
+		SET status = 'processing', attempts = attempts + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM message_outbox
+			WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT 1
+		)
+		RETURNING id, receive_id_type, receive_id, reply_to_message_id, reply_in_thread, msg_type, content, caller, uuid, attempts
+	`).Scan(&msg.id, &receiveIdType, &receiveId, &replyTo, &msg.replyInThread, &msg.msgType, &msg.content, &caller, &uuid, &msg.attempts)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	msg.receiveIdType = receiveIdType.String
+	msg.receiveId = receiveId.String
+	msg.replyToMessageId = replyTo.String
+	msg.caller = caller.String
+	msg.uuid = uuid.String
+	return msg, nil
+}
+
+// deliverOutboxMessage 投递消息，失败时按指数退避重试或转入死信
+func (s *FeishuService) deliverOutboxMessage(msg *outboxMessage) {
+	_, err := s.dispatchMessage(msg.receiveIdType, msg.receiveId, msg.msgType, msg.content, &SendOptions{
+		ReplyToMessageId: msg.replyToMessageId,
+		ReplyInThread:    msg.replyInThread,
+		Caller:           msg.caller,
+		Uuid:             outboxUuid(msg.id, msg.uuid), // 兼容升级前未保存幂等键的消息
+	})
+	if err == nil {
+		if _, err := s.db.Exec(`DELETE FROM message_outbox WHERE id = ?`, msg.id); err != nil {
+			log.Printf("Failed to remove delivered outbox message %s: %v", msg.id, err)
+		}
+		return
+	}
+
+	maxAttempts := s.config.OutboxMaxAttempts
+	if IsRetryableError(err) && msg.attempts < maxAttempts {
+		backoff := outboxBaseBackoff << (msg.attempts - 1)
+		if backoff > outboxMaxBackoff || backoff <= 0 {
+			backoff = outboxMaxBackoff
+		}
+		_, dbErr := s.db.Exec(`
+			UPDATE message_outbox
+			SET status = 'pending', last_error = ?, next_attempt_at = datetime('now', ?), updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?
+		`, err.Error(), fmt.Sprintf("+%d seconds", int(backoff.Seconds())), msg.id)
+		if dbErr != nil {
+			log.Printf("Failed to reschedule outbox message %s: %v", msg.id, dbErr)
+		}
+		log.Printf("Outbox message %s failed (attempt %d/%d), retry in %v: %v", msg.id, msg.attempts, maxAttempts, backoff, err)
+		return
+	}
+
+	if dbErr := s.moveToDeadLetter(msg, err); dbErr != nil {
+		log.Printf("Failed to move outbox message %s to dead letters: %v", msg.id, dbErr)
+		return
+	}
+	log.Printf("Outbox message %s moved to dead letters after %d attempts: %v", msg.id, msg.attempts, err)
+}
+
+// moveToDeadLetter 将消息从发件箱转入死信表
+func (s *FeishuService) moveToDeadLetter(msg *outboxMessage, sendErr error) error {
+	errorCode := 0
+	var feishuErr *FeishuError
+	if errors.As(sendErr, &feishuErr) {
+		errorCode = feishuErr.Code
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT OR REPLACE INTO message_dead_letters
+		(id, receive_id_type, receive_id, reply_to_message_id, reply_in_thread, msg_type, content, caller, uuid, attempts, error_code, last_error, created_at)
+		SELECT id, receive_id_type, receive_id, reply_to_message_id, reply_in_thread, msg_type, content, caller, uuid, attempts, ?, ?, created_at
+		FROM message_outbox WHERE id = ?
+	`, errorCode, sendErr.Error(), msg.id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM message_outbox WHERE id = ?`, msg.id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReplayDeadLetter 将死信重新放回发件箱投递
+func (s *FeishuService) ReplayDeadLetter(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var queued int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM message_outbox WHERE id = ?`, id).Scan(&queued); err != nil {
+		return err
+	}
+	if queued > 0 {
+		return ErrDeadLetterRequeued
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO message_outbox
+		(id, receive_id_type, receive_id, reply_to_message_id, reply_in_thread, msg_type, content, caller, uuid, created_at)
+		SELECT id, receive_id_type, receive_id, reply_to_message_id, reply_in_thread, msg_type, content, caller, uuid, created_at
+		FROM message_dead_letters WHERE id = ?
+	`, id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrDeadLetterNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM message_dead_letters WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.notifyOutbox()
+	return nil
+}
+
+// PurgeDeadLetters 删除死信，id 为空时删除全部，返回删除的条数
+func (s *FeishuService) PurgeDeadLetters(id string) (int64, error) {
+	var result sql.Result
+	var err error
+	if id == "" {
+		result, err = s.db.Exec(`DELETE FROM message_dead_letters`)
+	} else {
+		result, err = s.db.Exec(`DELETE FROM message_dead_letters WHERE id = ?`, id)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
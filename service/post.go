@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// 富文本(post)消息支持的语言
+const (
+	PostLangZhCN = "zh_cn"
+	PostLangEnUS = "en_us"
+	PostLangJaJP = "ja_jp"
+)
+
+// PostElement 富文本段落中的单个元素
+// Tag 取值: text、a、at、img、media、emotion、code_block、hr、md
+type PostElement struct {
+	Tag       string   `json:"tag"`
+	Text      string   `json:"text,omitempty"`
+	UnEscape  bool     `json:"un_escape,omitempty"`
+	Href      string   `json:"href,omitempty"`
+	UserID    string   `json:"user_id,omitempty"`
+	UserName  string   `json:"user_name,omitempty"`
+	ImageKey  string   `json:"image_key,omitempty"`
+	FileKey   string   `json:"file_key,omitempty"`
+	EmojiType string   `json:"emoji_type,omitempty"`
+	Language  string   `json:"language,omitempty"`
+	Style     []string `json:"style,omitempty"`
+}
+
+// PostContent 单一语言的富文本内容，Content 中每个元素为一个段落
+type PostContent struct {
+	Title   string           `json:"title"`
+	Content [][]*PostElement `json:"content"`
+}
+
+// PostMessage 多语言富文本消息，key 为语言（如 zh_cn、en_us）
+type PostMessage map[string]*PostContent
+
+// PostText 构建文本元素，style 可选 bold、italic、underline、lineThrough
+func PostText(text string, style ...string) *PostElement {
+	return &PostElement{Tag: "text", Text: text, Style: style}
+}
+
+// PostLink 构建超链接元素
+func PostLink(text, href string) *PostElement {
+	return &PostElement{Tag: "a", Text: text, Href: href}
+}
+
+// PostAt 构建@元素，userID 为 "all" 时表示@所有人
+func PostAt(userID string) *PostElement {
+	return &PostElement{Tag: "at", UserID: userID}
+}
+
+// PostImage 构建图片元素
+func PostImage(imageKey string) *PostElement {
+	return &PostElement{Tag: "img", ImageKey: imageKey}
+}
+
+// PostCodeBlock 构建代码块元素
+func PostCodeBlock(language, code string) *PostElement {
+	return &PostElement{Tag: "code_block", Language: language, Text: code}
+}
+
+// PostDivider 构建分割线元素
+func PostDivider() *PostElement {
+	return &PostElement{Tag: "hr"}
+}
+
+// Validate 校验富文本消息结构
+func (p PostMessage) Validate() error {
+	if len(p) == 0 {
+		return fmt.Errorf("post content is empty")
+	}
+	for lang, content := range p {
+		if content == nil {
+			return fmt.Errorf("post content for %s is empty", lang)
+		}
+		if content.Title == "" && len(content.Content) == 0 {
+			return fmt.Errorf("post content for %s has neither title nor paragraphs", lang)
+		}
+		for i, paragraph := range content.Content {
+			for j, elem := range paragraph {
+				if elem == nil || elem.Tag == "" {
+					return fmt.Errorf("post content for %s: paragraph %d element %d has no tag", lang, i, j)
+				}
+				switch elem.Tag {
+				case "a":
+					if elem.Href == "" {
+						return fmt.Errorf("post content for %s: link element requires href", lang)
+					}
+				case "at":
+					if elem.UserID == "" {
+						return fmt.Errorf("post content for %s: at element requires user_id", lang)
+					}
+				case "img":
+					if elem.ImageKey == "" {
+						return fmt.Errorf("post content for %s: img element requires image_key", lang)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// SendPostMessage 发送富文本消息
+func (s *FeishuService) SendPostMessage(receiveIdType, receiveId string, post PostMessage, opts ...SendOption) (*larkim.CreateMessageRespData, error) {
+	if err := post.Validate(); err != nil {
+		return nil, err
+	}
+
+	data, err := s.createMessage(receiveIdType, receiveId, "post", post, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("send post message failed: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 手机号、邮箱类型的接收者，发送消息时先解析为 user_id，未找到的接收者在发送前即返回错误
+const (
+	ReceiveIdTypeMobile = "mobile"
+	ReceiveIdTypeEmail  = "email"
+)
+
+// 手机号、邮箱解析结果的缓存时长，未找到的结果缓存时间较短
+const (
+	recipientCacheTTL        = time.Hour
+	recipientNegativeTTL     = 5 * time.Minute
+	batchGetUserIdsChunkSize = 50
+)
+
+// UnresolvedRecipientError 手机号或邮箱无法解析为用户
+type UnresolvedRecipientError struct {
+	ReceiveIdType string
+	ReceiveId     string
+}
+
+func (e *UnresolvedRecipientError) Error() string {
+	return fmt.Sprintf("recipient not found: %s=%s", e.ReceiveIdType, e.ReceiveId)
+}
+
+// recipientCache 手机号、邮箱到 user_id 的缓存
+type recipientCache struct {
+	mu      sync.RWMutex
+	entries map[string]*recipientCacheEntry
+}
+
+type recipientCacheEntry struct {
+	userId    string
+	expiresAt time.Time
+}
+
+func newRecipientCache() *recipientCache {
+	return &recipientCache{entries: make(map[string]*recipientCacheEntry)}
+}
+
+// get 返回缓存的 user_id，found 表示缓存是否命中（命中但 user_id 为空表示未找到该用户）
+func (c *recipientCache) get(key string) (userId string, found bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", false
+	}
+	return entry.userId, true
+}
+
+func (c *recipientCache) set(key, userId string) {
+	ttl := recipientCacheTTL
+	if userId == "" {
+		ttl = recipientNegativeTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = &recipientCacheEntry{userId: userId, expiresAt: time.Now().Add(ttl)}
+}
+
+func recipientKey(receiveIdType, receiveId string) string {
+	return receiveIdType + ":" + strings.ToLower(strings.TrimSpace(receiveId))
+}
+
+// needsResolve 判断接收者类型是否需要解析，手机号和邮箱都解析为 user_id，
+// 与手机号一致地在发送前发现未知的邮箱
+func needsResolve(receiveIdType string) bool {
+	return receiveIdType == ReceiveIdTypeMobile || receiveIdType == ReceiveIdTypeEmail
+}
+
+// resolveReceiveId 将手机号、邮箱类型的接收者解析为 user_id，其他类型原样返回
+func (s *FeishuService) resolveReceiveId(receiveIdType, receiveId string) (string, string, error) {
+	if !needsResolve(receiveIdType) {
+		return receiveIdType, receiveId, nil
+	}
+
+	var mobiles, emails []string
+	if receiveIdType == ReceiveIdTypeMobile {
+		mobiles = []string{receiveId}
+	} else {
+		emails = []string{receiveId}
+	}
+	resolved, err := s.ResolveUserIds(mobiles, emails)
+	if err != nil {
+		return "", "", err
+	}
+
+	userId := resolved[recipientKey(receiveIdType, receiveId)]
+	if userId == "" {
+		return "", "", &UnresolvedRecipientError{ReceiveIdType: receiveIdType, ReceiveId: receiveId}
+	}
+	return "user_id", userId, nil
+}
+
+// ResolveUserIds 批量将手机号和邮箱解析为 user_id，依次查询内存缓存、本地通讯录缓存和通讯录接口
+// 返回值的 key 为 "mobile:<手机号>" 或 "email:<邮箱>"，未找到的接收者不在结果中
+func (s *FeishuService) ResolveUserIds(mobiles, emails []string) (map[string]string, error) {
+	resolved := make(map[string]string)
+	var missMobiles, missEmails []string
+
+	for _, mobile := range mobiles {
+		key := recipientKey(ReceiveIdTypeMobile, mobile)
+		if userId, found := s.recipients.get(key); found {
+			if userId != "" {
+				resolved[key] = userId
+			}
+			continue
+		}
+		if userId := s.cachedUserId(ReceiveIdTypeMobile, mobile); userId != "" {
+			s.recipients.set(key, userId)
+			resolved[key] = userId
+			continue
+		}
+		missMobiles = append(missMobiles, mobile)
+	}
+	for _, email := range emails {
+		key := recipientKey(ReceiveIdTypeEmail, email)
+		if userId, found := s.recipients.get(key); found {
+			if userId != "" {
+				resolved[key] = userId
+			}
+			continue
+		}
+		if userId := s.cachedUserId(ReceiveIdTypeEmail, email); userId != "" {
+			s.recipients.set(key, userId)
+			resolved[key] = userId
+			continue
+		}
+		missEmails = append(missEmails, email)
+	}
+
+	for len(missMobiles) > 0 || len(missEmails) > 0 {
+		mobileChunk := takeChunk(&missMobiles, batchGetUserIdsChunkSize)
+		emailChunk := takeChunk(&missEmails, batchGetUserIdsChunkSize)
+
+		data, err := s.BatchGetUserIds(mobileChunk, emailChunk, false)
+		if err != nil {
+			return nil, err
+		}
+
+		found := make(map[string]string)
+		if data != nil {
+			for _, user := range data.UserList {
+				userId := getStringValue(user.UserId)
+				if user.Mobile != nil {
+					found[recipientKey(ReceiveIdTypeMobile, *user.Mobile)] = userId
+				}
+				if user.Email != nil {
+					found[recipientKey(ReceiveIdTypeEmail, *user.Email)] = userId
+				}
+			}
+		}
+
+		for _, mobile := range mobileChunk {
+			key := recipientKey(ReceiveIdTypeMobile, mobile)
+			s.recipients.set(key, found[key])
+			if found[key] != "" {
+				resolved[key] = found[key]
+			}
+		}
+		for _, email := range emailChunk {
+			key := recipientKey(ReceiveIdTypeEmail, email)
+			s.recipients.set(key, found[key])
+			if found[key] != "" {
+				resolved[key] = found[key]
+			}
+		}
+	}
+
+	return resolved, nil
+}
+
+// takeChunk 从切片头部取出最多 size 个元素
+func takeChunk(items *[]string, size int) []string {
+	n := len(*items)
+	if n > size {
+		n = size
+	}
+	chunk := (*items)[:n]
+	*items = (*items)[n:]
+	return chunk
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// 模板支持的消息类型
+const (
+	TemplateTypeText = "text"
+	TemplateTypePost = "post"
+	TemplateTypeCard = "interactive"
+)
+
+// templateFuncs 模板中可用的辅助函数
+var templateFuncs = template.FuncMap{
+	// json 将变量序列化为 JSON，用于在 post/卡片模板中安全地嵌入字符串
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+}
+
+// TemplateError 模板本身或渲染结果有误，如语法错误、缺少变量、渲染结果不是合法的 JSON
+type TemplateError struct {
+	Err error
+}
+
+func (e *TemplateError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// templateErrorf 创建 TemplateError
+func templateErrorf(format string, args ...interface{}) error {
+	return &TemplateError{Err: fmt.Errorf(format, args...)}
+}
+
+// ValidateTemplate 校验模板类型和语法
+func ValidateTemplate(msgType, content string) error {
+	switch msgType {
+	case TemplateTypeText, TemplateTypePost, TemplateTypeCard:
+	default:
+		return templateErrorf("unsupported template msg_type: %s", msgType)
+	}
+
+	if _, err := template.New("message").Funcs(templateFuncs).Option("missingkey=error").Parse(content); err != nil {
+		return templateErrorf("parse template failed: %v", err)
+	}
+	return nil
+}
+
+// RenderTemplate 使用变量渲染模板内容，缺失的变量会返回 TemplateError
+func RenderTemplate(content string, variables map[string]interface{}) (string, error) {
+	tmpl, err := template.New("message").Funcs(templateFuncs).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", templateErrorf("parse template failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, variables); err != nil {
+		return "", templateErrorf("render template failed: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// SendTemplateMessage 渲染模板并按模板类型发送消息，模板有误时返回 TemplateError
+func (s *FeishuService) SendTemplateMessage(receiveIdType, receiveId, msgType, content string, variables map[string]interface{}, opts ...SendOption) (*larkim.CreateMessageRespData, error) {
+	rendered, err := RenderTemplate(content, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// 发送前校验渲染结果，渲染结果不合法属于模板错误
+	switch msgType {
+	case TemplateTypeText:
+		if strings.TrimSpace(rendered) == "" {
+			return nil, templateErrorf("rendered text template is empty")
+		}
+		return s.SendTextMessage(receiveIdType, receiveId, rendered, opts...)
+	case TemplateTypePost:
+		var post PostMessage
+		if err := json.Unmarshal([]byte(rendered), &post); err != nil {
+			return nil, templateErrorf("rendered post template is not valid json: %v", err)
+		}
+		if err := post.Validate(); err != nil {
+			return nil, templateErrorf("rendered post template is invalid: %v", err)
+		}
+		return s.SendPostMessage(receiveIdType, receiveId, post, opts...)
+	case TemplateTypeCard:
+		var card map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(rendered), &card); err != nil {
+			return nil, templateErrorf("rendered card template is not a valid json object: %v", err)
+		}
+		if len(card) == 0 {
+			return nil, templateErrorf("rendered card template is empty")
+		}
+		return s.SendRawCardMessage(receiveIdType, receiveId, rendered, opts...)
+	default:
+		return nil, templateErrorf("unsupported template msg_type: %s", msgType)
+	}
+}
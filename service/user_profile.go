@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	larkcontact "github.com/larksuite/oapi-sdk-go/v3/service/contact/v3"
+)
+
+// 批量获取用户信息接口单次最多查询的用户数
+const userBatchSize = 50
+
+// GetUserProfiles 批量获取用户的完整信息，无权限或不存在的用户不在结果中
+func (s *FeishuService) GetUserProfiles(userIds []string) ([]*UserInfo, error) {
+	return s.getUserProfiles("user_id", userIds)
+}
+
+// getUserProfiles 按指定的用户ID类型（user_id、open_id、union_id）批量获取用户信息
+func (s *FeishuService) getUserProfiles(userIdType string, userIds []string) ([]*UserInfo, error) {
+	var users []*UserInfo
+	pending := append([]string{}, userIds...)
+	for len(pending) > 0 {
+		chunk := takeChunk(&pending, userBatchSize)
+
+		req := larkcontact.NewBatchUserReqBuilder().
+			UserIds(chunk).
+			UserIdType(userIdType).
+			DepartmentIdType("open_department_id").
+			Build()
+
+		resp, err := s.client.Contact.User.Batch(context.Background(), req)
+		if err != nil {
+			return nil, fmt.Errorf("batch get users request failed: %v", err)
+		}
+		if !resp.Success() {
+			return nil, fmt.Errorf("batch get users failed: %w", &FeishuError{Code: resp.Code, Msg: resp.Msg})
+		}
+		if resp.Data == nil {
+			continue
+		}
+
+		for _, user := range resp.Data.Items {
+			users = append(users, newUserInfo(user))
+		}
+	}
+	return users, nil
+}
+
+// fillUserProfiles 用完整的用户信息替换只有ID的搜索结果，获取失败时保留原结果
+func (s *FeishuService) fillUserProfiles(users []*UserInfo) []*UserInfo {
+	var userIds []string
+	for _, user := range users {
+		if user.UserID != "" {
+			userIds = append(userIds, user.UserID)
+		}
+	}
+	if len(userIds) == 0 {
+		return users
+	}
+
+	profiles, err := s.GetUserProfiles(userIds)
+	if err != nil {
+		log.Printf("Failed to get user profiles: %v", err)
+		return users
+	}
+
+	byId := make(map[string]*UserInfo, len(profiles))
+	for _, profile := range profiles {
+		byId[profile.UserID] = profile
+	}
+	for i, user := range users {
+		if profile, ok := byId[user.UserID]; ok {
+			users[i] = profile
+		}
+	}
+	return users
+}
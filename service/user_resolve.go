@@ -0,0 +1,146 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 支持互相转换的用户标识类型
+var userIdentifierTypes = map[string]bool{
+	"user_id":           true,
+	"open_id":           true,
+	"union_id":          true,
+	ReceiveIdTypeEmail:  true,
+	ReceiveIdTypeMobile: true,
+}
+
+// UserIdentifier 用户标识
+type UserIdentifier struct {
+	IdType string `json:"id_type"`
+	Id     string `json:"id"`
+}
+
+// ResolvedUser 用户标识的转换结果，包含该用户的所有ID形式
+type ResolvedUser struct {
+	IdType string    `json:"id_type"`
+	Id     string    `json:"id"`
+	Found  bool      `json:"found"`
+	User   *UserInfo `json:"user,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// IsUserIdentifierType 判断是否为支持转换的用户标识类型
+func IsUserIdentifierType(idType string) bool {
+	return userIdentifierTypes[idType]
+}
+
+// ResolveUsers 将任意类型的用户标识转换为包含全部ID形式的用户信息，结果顺序与输入一致
+// 依次查询本地通讯录缓存、手机号邮箱解析接口和批量获取用户信息接口
+func (s *FeishuService) ResolveUsers(identifiers []*UserIdentifier) ([]*ResolvedUser, error) {
+	results := make([]*ResolvedUser, len(identifiers))
+	for i, identifier := range identifiers {
+		if !IsUserIdentifierType(identifier.IdType) {
+			return nil, fmt.Errorf("unsupported id_type: %s", identifier.IdType)
+		}
+		results[i] = &ResolvedUser{IdType: identifier.IdType, Id: strings.TrimSpace(identifier.Id)}
+	}
+
+	// 本地通讯录缓存
+	var pending []*ResolvedUser
+	for _, result := range results {
+		users, err := s.findCachedUsers(result.IdType, result.Id)
+		if err != nil {
+			log.Printf("Failed to query contact cache: %v", err)
+		}
+		if len(users) > 0 {
+			result.Found = true
+			result.User = users[0]
+			continue
+		}
+		pending = append(pending, result)
+	}
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	// 手机号、邮箱先解析为 user_id
+	var mobiles, emails []string
+	for _, result := range pending {
+		switch result.IdType {
+		case ReceiveIdTypeMobile:
+			mobiles = append(mobiles, result.Id)
+		case ReceiveIdTypeEmail:
+			emails = append(emails, result.Id)
+		}
+	}
+	var resolved map[string]string
+	if len(mobiles) > 0 || len(emails) > 0 {
+		var err error
+		resolved, err = s.ResolveUserIds(mobiles, emails)
+		if err != nil {
+			log.Printf("Failed to resolve mobiles and emails: %v", err)
+			for _, result := range pending {
+				if result.IdType == ReceiveIdTypeMobile || result.IdType == ReceiveIdTypeEmail {
+					result.Error = err.Error()
+				}
+			}
+		}
+	}
+
+	// 按ID类型分组批量获取用户信息
+	lookups := make(map[string][]string)
+	for _, result := range pending {
+		idType, id := result.IdType, result.Id
+		if idType == ReceiveIdTypeMobile || idType == ReceiveIdTypeEmail {
+			id = resolved[recipientKey(idType, result.Id)]
+			if id == "" {
+				continue
+			}
+			idType = "user_id"
+			// 获取用户信息失败时至少返回 user_id
+			result.Found = true
+			result.User = &UserInfo{UserID: id}
+			if result.IdType == ReceiveIdTypeMobile {
+				result.User.Mobile = result.Id
+			} else {
+				result.User.Email = result.Id
+			}
+		}
+		lookups[idType] = append(lookups[idType], id)
+	}
+
+	profiles := make(map[string]*UserInfo)
+	for idType, ids := range lookups {
+		users, err := s.getUserProfiles(idType, ids)
+		if err != nil {
+			log.Printf("Failed to get user profiles by %s: %v", idType, err)
+			for _, result := range pending {
+				if result.IdType == idType && !result.Found {
+					result.Error = err.Error()
+				}
+			}
+			continue
+		}
+		for _, user := range users {
+			for _, key := range []string{"user_id:" + user.UserID, "open_id:" + user.OpenID, "union_id:" + user.UnionID} {
+				if !strings.HasSuffix(key, ":") {
+					profiles[key] = user
+				}
+			}
+		}
+	}
+
+	for _, result := range pending {
+		key := result.IdType + ":" + result.Id
+		if result.User != nil {
+			key = "user_id:" + result.User.UserID
+		}
+		if profile, ok := profiles[key]; ok {
+			result.Found = true
+			result.User = profile
+		}
+	}
+
+	return results, nil
+}